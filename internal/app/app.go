@@ -1,9 +1,13 @@
 package app
 
 import (
+	"fmt"
+
+	"github.com/xdagiz/xytz/internal/config"
 	"github.com/xdagiz/xytz/internal/models"
 	"github.com/xdagiz/xytz/internal/styles"
 	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/utils"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -23,12 +27,18 @@ type Model struct {
 	VideoList     models.VideoListModel
 	FormatList    models.FormatListModel
 	Download      models.DownloadModel
+	Library       models.LibraryModel
+	Preflight     models.PreflightModel
 	SelectedVideo types.VideoItem
 	ErrMsg        string
+	Notice        string
+	RetryNote     string
+	Notifications config.Notifications
+	termProgress  string
 }
 
 func (m *Model) Init() tea.Cmd {
-	return tea.Batch(m.Search.Init(), m.Spinner.Tick, m.Download.Init())
+	return tea.Batch(m.Search.Init(), m.Spinner.Tick, m.Download.Init(), utils.RateScheduleTick())
 }
 
 func NewModel() *Model {
@@ -36,12 +46,59 @@ func NewModel() *Model {
 	sp.Spinner = spinner.Dot
 	sp.Style = sp.Style.Foreground(styles.PinkColor)
 
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.GetDefault()
+	}
+
 	return &Model{
-		State:      types.StateSearchInput,
-		Spinner:    sp,
-		Search:     models.NewSearchModel(),
-		VideoList:  models.NewVideoListModel(),
-		FormatList: models.NewFormatListModel(),
-		Download:   models.NewDownloadModel(),
+		Notifications: cfg.Notifications,
+		State:         types.StateSearchInput,
+		Spinner:       sp,
+		Search:        models.NewSearchModel(),
+		VideoList:     models.NewVideoListModel(),
+		FormatList:    models.NewFormatListModel(),
+		Download:      models.NewDownloadModel(),
+		Library:       models.NewLibraryModel(),
+	}
+}
+
+// notify reports a finished or failed job through the configured
+// notifications.
+func (m *Model) notify(jobID int, outcome, errMsg string) {
+	job := m.Download.Job(jobID)
+	if job == nil || job.Status == types.JobCancelled {
+		return
+	}
+
+	message := job.Video.Title()
+	if errMsg != "" {
+		message = fmt.Sprintf("%s (%s)", message, errMsg)
+	}
+
+	utils.Notify(m.Notifications, outcome, message)
+}
+
+// reportProgress mirrors the download progress in the terminal tab or
+// taskbar, writing only when the reported value changes.
+func (m *Model) reportProgress() {
+	if !m.Notifications.Progress {
+		return
+	}
+
+	state, percent := m.Download.TerminalProgress()
+	progress := fmt.Sprintf("%d;%d", state, percent)
+	if progress == m.termProgress {
+		return
+	}
+
+	m.termProgress = progress
+	utils.SetTerminalProgress(state, percent)
+}
+
+// ClearTerminalProgress removes the progress indicator before exiting.
+func (m *Model) ClearTerminalProgress() {
+	if m.termProgress != "" {
+		utils.SetTerminalProgress(utils.ProgressClear, 0)
 	}
 }
@@ -1,8 +1,12 @@
 package app
 
 import (
+	"cmp"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/xdagiz/xytz/internal/config"
 	"github.com/xdagiz/xytz/internal/models"
 	"github.com/xdagiz/xytz/internal/types"
 	"github.com/xdagiz/xytz/internal/utils"
@@ -22,6 +26,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.VideoList = m.VideoList.HandleResize(m.Width, m.Height)
 		m.FormatList = m.FormatList.HandleResize(m.Width, m.Height)
 		m.Download = m.Download.HandleResize(m.Width, m.Height)
+		m.Library = m.Library.HandleResize(m.Width, m.Height)
 	case spinner.TickMsg:
 		var spinnerCmd tea.Cmd
 		m.Spinner, spinnerCmd = m.Spinner.Update(msg)
@@ -29,29 +34,33 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case types.StartSearchMsg:
 		m.State = types.StateLoading
 		m.LoadingType = "search"
+		m.RetryNote = ""
 		m.CurrentQuery = strings.TrimSpace(msg.Query)
 		m.VideoList.IsChannelSearch = false
 		m.VideoList.IsPlaylistSearch = false
 		m.VideoList.ChannelName = ""
 		m.VideoList.PlaylistName = ""
 		m.VideoList.PlaylistURL = ""
-		cmd = utils.PerformSearch(msg.Query, m.Search.SortBy.GetSPParam())
+		cmd = utils.PerformSearch(m.Program, msg.Query, m.Search.SortBy.GetSPParam())
 		m.ErrMsg = ""
 		m.Search.Input.SetValue("")
 	case types.StartFormatMsg:
 		m.State = types.StateLoading
 		m.LoadingType = "format"
+		m.RetryNote = ""
 		m.FormatList.URL = msg.URL
 		m.FormatList.SelectedVideo = msg.SelectedVideo
 		m.SelectedVideo = msg.SelectedVideo
 		m.FormatList.DownloadOptions = m.Search.DownloadOptions
 		m.FormatList.ResetTab()
-		cmd = utils.FetchFormats(msg.URL)
+		cmd = utils.FetchFormats(m.Program, msg.URL)
 		m.ErrMsg = ""
 	case types.SearchResultMsg:
 		m.LoadingType = ""
 		m.Videos = msg.Videos
 		m.VideoList.List.SetItems(msg.Videos)
+		m.VideoList.ClearSelection()
+		m.VideoList.RefreshArchive()
 		m.VideoList.CurrentQuery = m.CurrentQuery
 		m.VideoList.ErrMsg = msg.Err
 		m.State = types.StateVideoList
@@ -59,67 +68,155 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case types.FormatResultMsg:
 		m.LoadingType = ""
-		m.FormatList.SetFormats(msg.VideoFormats, msg.AudioFormats, msg.ThumbnailFormats, msg.AllFormats)
+		m.FormatList.SetFormats(msg.VideoFormats, msg.AudioFormats, msg.ThumbnailFormats, msg.SubtitleTracks, msg.AllFormats)
+		m.FormatList.FormatSizes = msg.FormatSizes
 		if msg.VideoInfo.ID != "" {
-			m.FormatList.SelectedVideo = msg.VideoInfo
+			info := msg.VideoInfo
+			if info.PlaylistIndex == 0 {
+				info.PlaylistIndex = m.FormatList.SelectedVideo.PlaylistIndex
+				info.PlaylistTitle = m.FormatList.SelectedVideo.PlaylistTitle
+			}
+			m.FormatList.SelectedVideo = info
 		}
 		m.State = types.StateFormatList
 		m.ErrMsg = msg.Err
 		return m, nil
 	case types.StartDownloadMsg:
+		if msg.Skipped > 0 && len(msg.URLs) == 0 && msg.URL == "" {
+			return m, m.setNotice(fmt.Sprintf("Skipped %d already downloaded videos", msg.Skipped))
+		}
+		reqs, videos := m.downloadRequests(msg)
+		if m.needsPreflight(msg) {
+			return m, utils.Preflight(msg, reqs, videos)
+		}
+		var noticeCmd tea.Cmd
+		if msg.Skipped > 0 {
+			noticeCmd = m.setNotice(fmt.Sprintf("Skipped %d already downloaded videos", msg.Skipped))
+		}
 		m.State = types.StateDownload
-		m.Download.Completed = false
-		m.Download.Cancelled = false
-		if m.SelectedVideo.ID == "" {
-			m.Download.SelectedVideo = m.FormatList.SelectedVideo
-		} else {
-			m.Download.SelectedVideo = m.SelectedVideo
+		if len(msg.URLs) > 0 {
+			cmd = m.startBatchDownload(reqs, videos)
+			return m, tea.Batch(cmd, noticeCmd)
 		}
+		req, video := reqs[0], videos[0]
+		req.JobID = utils.NextDownloadID()
 		m.LoadingType = "download"
-		cmd = utils.StartDownload(m.Program, msg.URL, msg.FormatID, m.SelectedVideo.Title(), m.Search.DownloadOptions)
-		return m, cmd
+		cmd = utils.StartDownload(m.Program, req)
+		if msg.Playlist {
+			progressCmd := m.Download.AddPlaylistJob(req.JobID, video, msg.URL, msg.FormatID, utils.DestinationDir(req, types.VideoItem{}), msg.Videos)
+			return m, tea.Batch(cmd, progressCmd)
+		}
+		progressCmd := m.Download.AddJob(req.JobID, video, msg.URL, msg.FormatID, utils.DestinationDir(req, video))
+		if job := m.Download.Job(req.JobID); job != nil {
+			job.StreamSizes = msg.StreamSizes
+		}
+		return m, tea.Batch(cmd, progressCmd)
+	case types.PreflightMsg:
+		if msg.OK() {
+			start := msg.Start
+			start.Checked = true
+			return m, func() tea.Msg {
+				return start
+			}
+		}
+		m.Preflight.Show(msg)
+		return m, nil
+	case types.QuickDownloadMsg:
+		return m, m.startQuickDownload(msg)
 	case types.StartResumeDownloadMsg:
 		m.State = types.StateDownload
-		m.Download.Completed = false
-		m.Download.Cancelled = false
-		m.Download.SelectedVideo = types.VideoItem{VideoTitle: msg.Title}
+		req := msg.Request
+		req.JobID = utils.NextDownloadID()
+		if req.Options == nil {
+			req.Options = m.Search.DownloadOptions
+		}
+		video := types.VideoItem{
+			ID:         req.VideoID,
+			VideoTitle: req.Title,
+			Channel:    req.Channel,
+			Uploader:   req.Channel,
+			Duration:   req.Duration,
+		}
+		var progressCmd tea.Cmd
+		if req.Playlist {
+			progressCmd = m.Download.AddPlaylistJob(req.JobID, video, req.URL, req.FormatID, utils.DestinationDir(req, types.VideoItem{}), nil)
+		} else {
+			progressCmd = m.Download.AddJob(req.JobID, video, req.URL, req.FormatID, utils.DestinationDir(req, video))
+		}
 		m.LoadingType = "download"
-		cmd = utils.StartDownload(m.Program, msg.URL, msg.FormatID, msg.Title, m.Search.DownloadOptions)
+		cmd = utils.StartDownload(m.Program, req)
+		return m, tea.Batch(cmd, progressCmd)
+	case types.RateScheduleTickMsg:
+		return m, tea.Batch(utils.ApplyRateSchedule(), utils.RateScheduleTick())
+	case types.DownloadStartedMsg, types.ProgressMsg, types.PlaylistItemMsg, types.RateLimitMsg, types.DownloadRetryMsg:
+		m.Download, cmd = m.Download.Update(msg)
+		m.reportProgress()
 		return m, cmd
 	case types.DownloadResultMsg:
 		m.LoadingType = ""
+		if job := m.Download.Job(msg.JobID); job != nil && job.Status != types.JobCancelled {
+			m.ErrMsg = msg.Err
+		}
+		if !msg.Cancelled {
+			m.notify(msg.JobID, "Download failed", msg.Err)
+		}
+		m.Download, cmd = m.Download.Update(msg)
+		m.reportProgress()
+		return m, cmd
+	case types.DownloadFinishedMsg:
+		m.LoadingType = ""
+		m.VideoList.RefreshArchive()
+		m.notify(msg.JobID, "Download complete", "")
+		m.Download, cmd = m.Download.Update(msg)
+		m.reportProgress()
+		return m, cmd
+	case types.HookResultMsg:
 		if msg.Err != "" {
-			if !m.Download.Cancelled {
-				m.ErrMsg = msg.Err
-				m.State = types.StateSearchInput
-			}
-		} else {
-			m.Download.Completed = true
+			return m, m.setNotice(fmt.Sprintf("Hook %s failed: %s", msg.Event, msg.Err))
 		}
+		return m, m.setNotice(fmt.Sprintf("Hook %s finished", msg.Event))
+	case types.ProfileSelectedMsg:
+		return m, m.selectProfile(msg)
+	case types.AuthResultMsg:
+		m.Search.Auth.SetResult(msg)
+		return m, nil
+	case types.ClearNoticeMsg:
+		m.Notice = ""
 		return m, nil
 	case types.DownloadCompleteMsg:
 		m.State = types.StateSearchInput
 		m.Search.Input.SetValue("")
 		m.SelectedVideo = types.VideoItem{}
-		m.Download.Progress.SetPercent(0)
-		m.Download.CurrentSpeed = ""
-		m.Download.CurrentETA = ""
-		return m, nil
-	case types.PauseDownloadMsg:
-		m.Download.Paused = true
-		return m, nil
-	case types.ResumeDownloadMsg:
-		m.Download.Paused = false
-		return m, nil
+		cmd = m.Download.ClearFinished()
+		return m, cmd
+	case types.PauseDownloadMsg, types.ResumeDownloadMsg:
+		m.Download, cmd = m.Download.Update(msg)
+		m.reportProgress()
+		return m, cmd
 	case types.CancelDownloadMsg:
-		m.Download.Cancelled = true
-		if m.SelectedVideo.ID == "" {
-			m.State = types.StateSearchInput
-		} else {
-			m.State = types.StateVideoList
-		}
+		m.Download, cmd = m.Download.Update(msg)
+		m.reportProgress()
 		m.ErrMsg = "Download cancelled"
-		m.FormatList.List.ResetSelected()
+		if !m.Download.HasRunningJobs() {
+			if m.SelectedVideo.ID == "" {
+				m.State = types.StateSearchInput
+			} else {
+				m.State = types.StateVideoList
+			}
+			m.FormatList.List.ResetSelected()
+		}
+		return m, cmd
+	case types.ShowDownloadsMsg:
+		m.State = types.StateDownload
+		m.ErrMsg = ""
+		return m, nil
+	case types.ShowLibraryMsg:
+		m.Library.Load()
+		m.State = types.StateLibrary
+		m.ErrMsg = ""
+		return m, nil
+	case types.FetchRetryMsg:
+		m.RetryNote = fmt.Sprintf("%s. Retrying in %s (attempt %d/%d)", msg.Err, msg.Delay, msg.Attempt, msg.MaxAttempts)
 		return m, nil
 	case types.CancelSearchMsg:
 		m.State = types.StateSearchInput
@@ -135,16 +232,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case types.StartChannelURLMsg:
 		m.State = types.StateLoading
 		m.LoadingType = "channel"
+		m.RetryNote = ""
 		m.VideoList.IsChannelSearch = true
 		m.VideoList.IsPlaylistSearch = false
 		m.VideoList.ChannelName = msg.ChannelName
 		m.VideoList.PlaylistURL = ""
-		cmd = utils.PerformChannelSearch(msg.ChannelName)
+		cmd = utils.PerformChannelSearch(m.Program, msg.ChannelName)
 		m.ErrMsg = ""
 		return m, cmd
 	case types.StartPlaylistURLMsg:
 		m.State = types.StateLoading
 		m.LoadingType = "playlist"
+		m.RetryNote = ""
 		m.CurrentQuery = strings.TrimSpace(msg.Query)
 		m.VideoList.IsPlaylistSearch = true
 		m.VideoList.IsChannelSearch = false
@@ -165,7 +264,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.VideoList.PlaylistURL = "https://www.youtube.com/playlist?list=" + msg.Query
 		}
-		cmd = utils.PerformPlaylistSearch(msg.Query)
+		cmd = utils.PerformPlaylistSearch(m.Program, msg.Query)
 		m.ErrMsg = ""
 		return m, cmd
 	case types.BackFromVideoListMsg:
@@ -181,6 +280,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlC:
 			return m, tea.Quit
 		}
+		if m.Preflight.Visible {
+			m.Preflight, cmd = m.Preflight.Update(msg)
+			return m, cmd
+		}
 		switch m.State {
 		case types.StateSearchInput:
 			m.Search, cmd = m.Search.Update(msg)
@@ -195,9 +298,21 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case types.StateVideoList:
+			if m.VideoList.PolicyVisible {
+				m.VideoList, cmd = m.VideoList.Update(msg)
+				return m, cmd
+			}
+			switch msg.String() {
+			case "esc":
+				if len(m.VideoList.Selected) > 0 && m.VideoList.List.FilterState() == list.Unfiltered {
+					m.VideoList.ClearSelection()
+					return m, nil
+				}
+			}
 			switch msg.String() {
 			case "b", "esc":
 				if m.VideoList.List.FilterState() == list.Unfiltered {
+					m.VideoList.ClearSelection()
 					m.State = types.StateSearchInput
 					m.ErrMsg = ""
 					m.Search.Input.SetValue("")
@@ -210,7 +325,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case types.StateFormatList:
 			switch msg.String() {
 			case "b", "esc":
-				if m.FormatList.ActiveTab != models.FormatTabCustom {
+				if m.FormatList.ActiveTab != models.FormatTabCustom && !m.FormatList.SectionsVisible && !m.FormatList.Profiles.Visible {
 					if m.FormatList.List.FilterState() == list.Unfiltered {
 						if m.SelectedVideo.ID == "" {
 							m.State = types.StateSearchInput
@@ -227,13 +342,29 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case types.StateDownload:
 			switch msg.String() {
 			case "b":
-				if m.Download.Completed || m.Download.Cancelled {
+				if m.Download.RateInputVisible {
+					break
+				}
+				if m.FormatList.URL != "" {
 					m.State = types.StateFormatList
 					m.FormatList.List.ResetSelected()
+				} else {
+					m.State = types.StateSearchInput
 				}
 				m.ErrMsg = ""
 				return m, nil
 			}
+		case types.StateLibrary:
+			switch msg.String() {
+			case "b", "esc":
+				if m.Library.List.FilterState() == list.Unfiltered && m.Library.ConfirmDelete == nil {
+					m.State = types.StateSearchInput
+					m.ErrMsg = ""
+					m.Search.Input.SetValue("")
+					return m, nil
+				}
+			}
+			m.Library, cmd = m.Library.Update(msg)
 		}
 	case tea.MouseMsg:
 		switch m.State {
@@ -246,6 +377,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.VideoList, cmd = m.VideoList.Update(msg)
 		case types.StateFormatList:
 			m.FormatList, cmd = m.FormatList.Update(msg)
+		case types.StateLibrary:
+			m.Library, cmd = m.Library.Update(msg)
 		}
 		return m, cmd
 	}
@@ -257,3 +390,184 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	return m, cmd
 }
+
+// needsPreflight reports whether the destination should be checked for free
+// space and existing files before a download starts. Thumbnails and subtitles
+// are small sidecar files and are written without asking.
+func (m *Model) needsPreflight(msg types.StartDownloadMsg) bool {
+	if msg.Checked || msg.Thumbnail != nil || (msg.URL == "" && len(msg.URLs) == 0) {
+		return false
+	}
+
+	return msg.Subtitles == nil || msg.Subtitles.Mode != types.SubtitleOnly
+}
+
+func (m *Model) downloadVideo(msg types.StartDownloadMsg) types.VideoItem {
+	if msg.Video != nil {
+		return *msg.Video
+	}
+
+	if m.SelectedVideo.ID != "" {
+		return m.SelectedVideo
+	}
+
+	return m.FormatList.SelectedVideo
+}
+
+// downloadRequests builds the requests for a download, one per video of a
+// batch. Job IDs are assigned when the downloads start.
+func (m *Model) downloadRequests(msg types.StartDownloadMsg) ([]types.DownloadRequest, []types.VideoItem) {
+	if len(msg.URLs) > 0 {
+		reqs := make([]types.DownloadRequest, 0, len(msg.URLs))
+		videos := make([]types.VideoItem, 0, len(msg.URLs))
+		for i, url := range msg.URLs {
+			video := types.VideoItem{}
+			if i < len(msg.Videos) {
+				video = msg.Videos[i]
+			}
+
+			reqs = append(reqs, types.DownloadRequest{
+				URL:          url,
+				FormatID:     msg.FormatID,
+				Title:        video.Title(),
+				VideoID:      video.ID,
+				Channel:      cmp.Or(video.Uploader, video.Channel),
+				Duration:     video.Duration,
+				Options:      m.Search.DownloadOptions,
+				Profile:      m.Search.Profile,
+				SkipArchived: true,
+				Overwrite:    msg.Overwrite,
+			})
+			videos = append(videos, video)
+		}
+
+		return reqs, videos
+	}
+
+	if msg.Playlist {
+		video := types.VideoItem{VideoTitle: "Playlist: " + m.VideoList.PlaylistName}
+		if msg.Video != nil {
+			video = *msg.Video
+		}
+
+		return []types.DownloadRequest{{
+			URL:          msg.URL,
+			FormatID:     msg.FormatID,
+			Title:        video.Title(),
+			Options:      m.Search.DownloadOptions,
+			Profile:      m.Search.Profile,
+			Playlist:     true,
+			SkipArchived: true,
+		}}, []types.VideoItem{video}
+	}
+
+	video := m.downloadVideo(msg)
+	return []types.DownloadRequest{m.downloadRequest(msg, video)}, []types.VideoItem{video}
+}
+
+func (m *Model) downloadRequest(msg types.StartDownloadMsg, video types.VideoItem) types.DownloadRequest {
+	return types.DownloadRequest{
+		URL:            msg.URL,
+		FormatID:       msg.FormatID,
+		Title:          video.Title(),
+		VideoID:        video.ID,
+		Channel:        cmp.Or(video.Uploader, video.Channel),
+		Duration:       video.Duration,
+		Options:        m.Search.DownloadOptions,
+		Profile:        m.Search.Profile,
+		OutputDir:      msg.OutputDir,
+		OutputTemplate: msg.OutputTemplate,
+		AudioFormat:    msg.AudioFormat,
+		AudioQuality:   msg.AudioQuality,
+		Sections:       msg.Sections,
+		ForceKeyframes: msg.ForceKeyframes,
+		Subtitles:      msg.Subtitles,
+		Thumbnail:      msg.Thumbnail,
+		Overwrite:      msg.Overwrite,
+	}
+}
+
+func (m *Model) startBatchDownload(reqs []types.DownloadRequest, videos []types.VideoItem) tea.Cmd {
+	var cmds, downloads []tea.Cmd
+	for i, req := range reqs {
+		req.JobID = utils.NextDownloadID()
+		cmds = append(cmds, m.Download.AddJob(req.JobID, videos[i], req.URL, req.FormatID, utils.DestinationDir(req, videos[i])))
+		downloads = append(downloads, utils.StartDownload(m.Program, req))
+	}
+
+	return tea.Batch(append(cmds, tea.Sequence(downloads...))...)
+}
+
+// quickFormat is the format used when the format list is skipped: the active
+// profile's, or the configured default.
+func (m *Model) quickFormat() string {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.GetDefault()
+	}
+
+	if m.Search.Profile != "" {
+		if profile, ok := cfg.FindProfile(m.Search.Profile); ok && profile.Format != "" {
+			return profile.Format
+		}
+	}
+
+	return cmp.Or(cfg.DefaultFormat, config.DefaultFormat)
+}
+
+func (m *Model) startQuickDownload(msg types.QuickDownloadMsg) tea.Cmd {
+	start := types.StartDownloadMsg{
+		FormatID: m.quickFormat(),
+		URLs:     msg.URLs,
+		Videos:   msg.Videos,
+		Skipped:  msg.Skipped,
+	}
+
+	if msg.URL != "" {
+		url := strings.TrimSpace(msg.URL)
+		start.URL = url
+		start.Playlist = utils.IsPlaylistURL(url)
+		start.Video = &types.VideoItem{ID: utils.ExtractVideoID(url), VideoTitle: url}
+	}
+
+	return func() tea.Msg {
+		return start
+	}
+}
+
+func (m *Model) selectProfile(msg types.ProfileSelectedMsg) tea.Cmd {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.GetDefault()
+	}
+
+	if msg.Name == "" {
+		m.Search.SetProfile(cfg, nil)
+		m.FormatList.SetProfile(cfg, nil)
+		m.FormatList.DownloadOptions = m.Search.DownloadOptions
+		return m.setNotice("Profile cleared")
+	}
+
+	profile, ok := cfg.FindProfile(msg.Name)
+	if !ok {
+		return m.setNotice(fmt.Sprintf("Unknown profile: %s", msg.Name))
+	}
+
+	m.Search.SetProfile(cfg, &profile)
+	m.FormatList.SetProfile(cfg, &profile)
+	m.FormatList.DownloadOptions = m.Search.DownloadOptions
+	noticeCmd := m.setNotice("Using profile " + profile.Name)
+
+	if msg.Download && profile.Format != "" && m.State == types.StateFormatList {
+		return tea.Batch(noticeCmd, m.FormatList.ProfileDownload(profile.Format))
+	}
+
+	return noticeCmd
+}
+
+func (m *Model) setNotice(notice string) tea.Cmd {
+	m.Notice = notice
+	return tea.Tick(5*time.Second, func(time.Time) tea.Msg {
+		return types.ClearNoticeMsg{}
+	})
+}
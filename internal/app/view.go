@@ -15,12 +15,14 @@ import (
 
 type StatusBarConfig struct {
 	HasError      bool
+	IsPlaylist    bool
 	HelpVisible   bool
 	IsPaused      bool
 	IsCompleted   bool
 	IsCancelled   bool
 	Keys          models.StatusKeys
 	ResumeVisible bool
+	MultipleJobs  bool
 }
 
 func getStatusBarText(state types.State, cfg StatusBarConfig, helpKeys models.HelpKeys) string {
@@ -67,36 +69,56 @@ func getStatusBarText(state types.State, cfg StatusBarConfig, helpKeys models.He
 				Enter: cfg.Keys.Enter,
 			})
 		}
+		keys := models.StatusKeys{
+			Quit:      cfg.Keys.Quit,
+			Back:      cfg.Keys.Back,
+			Select:    cfg.Keys.Select,
+			SelectAll: cfg.Keys.SelectAll,
+			Quick:     cfg.Keys.Quick,
+		}
+		if cfg.IsPlaylist {
+			keys.Playlist = cfg.Keys.Playlist
+		}
+		return models.FormatKeysForStatusBar(keys)
+	case types.StateFormatList:
 		return models.FormatKeysForStatusBar(models.StatusKeys{
-			Quit: cfg.Keys.Quit,
-			Back: cfg.Keys.Back,
+			Quit:     cfg.Keys.Quit,
+			Back:     cfg.Keys.Back,
+			Tab:      cfg.Keys.Tab,
+			Sections: cfg.Keys.Sections,
+			Profiles: cfg.Keys.Profiles,
 		})
-	case types.StateFormatList:
+	case types.StateLibrary:
 		return models.FormatKeysForStatusBar(models.StatusKeys{
-			Quit: cfg.Keys.Quit,
-			Back: cfg.Keys.Back,
-			Tab:  cfg.Keys.Tab,
+			Back:       cfg.Keys.Back,
+			Filter:     cfg.Keys.Filter,
+			Open:       cfg.Keys.Open,
+			Folder:     cfg.Keys.Folder,
+			Redownload: cfg.Keys.Redownload,
+			Remove:     cfg.Keys.Remove,
+			Delete:     cfg.Keys.Delete,
 		})
 	case types.StateDownload:
-		if cfg.IsCompleted || cfg.IsCancelled {
-			return models.FormatKeysForStatusBar(models.StatusKeys{
-				Quit:  cfg.Keys.Quit,
-				Back:  cfg.Keys.Back,
-				Enter: cfg.Keys.Enter,
-			})
+		keys := models.StatusKeys{
+			Quit: cfg.Keys.Quit,
+			Back: cfg.Keys.Back,
 		}
-		if cfg.IsPaused {
-			return models.FormatKeysForStatusBar(models.StatusKeys{
-				Quit:   cfg.Keys.Quit,
-				Pause:  cfg.Keys.Pause,
-				Cancel: cfg.Keys.Cancel,
-			})
+		if cfg.IsCompleted {
+			keys.Enter = cfg.Keys.Enter
+			keys.Open = cfg.Keys.Open
+			keys.Folder = cfg.Keys.Folder
+		} else if cfg.IsCancelled {
+			keys.Enter = cfg.Keys.Enter
+		} else {
+			keys.Pause = cfg.Keys.Pause
+			keys.Cancel = cfg.Keys.Cancel
+			keys.RateLimit = cfg.Keys.RateLimit
 		}
-		return models.FormatKeysForStatusBar(models.StatusKeys{
-			Quit:   cfg.Keys.Quit,
-			Pause:  cfg.Keys.Pause,
-			Cancel: cfg.Keys.Cancel,
-		})
+		if cfg.MultipleJobs {
+			keys.Up = cfg.Keys.Up
+			keys.Down = cfg.Keys.Down
+		}
+		return models.FormatKeysForStatusBar(keys)
 	default:
 		return models.FormatKeysForStatusBar(models.StatusKeys{
 			Quit: cfg.Keys.Quit,
@@ -121,23 +143,43 @@ func (m *Model) View() string {
 		content = m.FormatList.View()
 	case types.StateDownload:
 		content = m.Download.View()
+	case types.StateLibrary:
+		content = m.Library.View()
+	}
+
+	if m.Preflight.Visible {
+		content = "\n" + styles.CustomFormatContainerStyle.Render(m.Preflight.View())
 	}
 
 	statusCfg := StatusBarConfig{
 		HasError:      m.VideoList.ErrMsg != "",
+		IsPlaylist:    m.VideoList.IsPlaylistSearch && m.VideoList.PlaylistURL != "",
 		HelpVisible:   m.Search.Help.Visible,
-		IsPaused:      m.Download.Paused,
-		IsCompleted:   m.Download.Completed,
-		IsCancelled:   m.Download.Cancelled,
 		Keys:          models.GetStatusKeys(m.State, m.Search.Help.Visible, m.Search.ResumeList.Visible, m.Search.ResumeList.Keys),
 		ResumeVisible: m.Search.ResumeList.Visible,
+		MultipleJobs:  len(m.Download.Jobs) > 1,
+	}
+
+	if job := m.Download.ActiveJob(); job != nil {
+		statusCfg.IsPaused = job.Status == types.JobPaused
+		statusCfg.IsCompleted = job.Status == types.JobCompleted
+		statusCfg.IsCancelled = job.Status == types.JobCancelled || job.Status == types.JobFailed
 	}
 
 	left := getStatusBarText(m.State, statusCfg, m.Search.Help.Keys)
 
 	right := ""
+	rightText := ""
+	rightStyle := lipgloss.NewStyle()
 	if m.ErrMsg != "" {
-		right = lipgloss.NewStyle().Foreground(styles.ErrorColor).Render("⚠ " + m.ErrMsg)
+		rightText = "⚠ " + m.ErrMsg
+		rightStyle = rightStyle.Foreground(styles.ErrorColor)
+	} else if m.Notice != "" {
+		rightText = "● " + m.Notice
+		rightStyle = rightStyle.Foreground(styles.InfoColor)
+	}
+	if rightText != "" {
+		right = rightStyle.Render(rightText)
 	}
 
 	var statusBar string
@@ -149,7 +191,7 @@ func (m *Model) View() string {
 		rightSpace := availableWidth - leftWidth
 
 		if rightWidth > rightSpace && rightSpace > 0 {
-			right = lipgloss.NewStyle().Foreground(styles.ErrorColor).Width(rightSpace).MaxWidth(rightSpace).Render("⚠ " + m.ErrMsg)
+			right = rightStyle.Width(rightSpace).MaxWidth(rightSpace).Render(rightText)
 		}
 
 		statusBar = styles.StatusBarStyle.Height(1).Width(m.Width).Render(left + lipgloss.PlaceHorizontal(availableWidth-leftWidth, lipgloss.Right, right))
@@ -182,6 +224,10 @@ func (m *Model) LoadingView() string {
 	}
 
 	fmt.Fprintf(&s, "\n%s %s\n", m.Spinner.View(), loadingText)
+	if m.RetryNote != "" {
+		s.WriteString(styles.WarningMessageStyle.Render("⟳ " + m.RetryNote))
+		s.WriteRune('\n')
+	}
 
 	return s.String()
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,15 +13,161 @@ import (
 const ConfigFileName = "config.yaml"
 
 type Config struct {
-	SearchLimit         int    `yaml:"search_limit"`
-	DefaultDownloadPath string `yaml:"default_download_path"`
-	DefaultFormat       string `yaml:"default_format"`
-	SortByDefault       string `yaml:"sort_by_default"`
-	EmbedSubtitles      bool   `yaml:"embed_subtitles"`
-	EmbedMetadata       bool   `yaml:"embed_metadata"`
-	EmbedChapters       bool   `yaml:"embed_chapters"`
-	FFmpegPath          string `yaml:"ffmpeg_path"`
-	YTDLPPath           string `yaml:"yt_dlp_path"`
+	SearchLimit            int           `yaml:"search_limit"`
+	DefaultDownloadPath    string        `yaml:"default_download_path"`
+	DefaultFormat          string        `yaml:"default_format"`
+	SortByDefault          string        `yaml:"sort_by_default"`
+	EmbedSubtitles         bool          `yaml:"embed_subtitles"`
+	EmbedMetadata          bool          `yaml:"embed_metadata"`
+	EmbedChapters          bool          `yaml:"embed_chapters"`
+	EmbedThumbnail         bool          `yaml:"embed_thumbnail"`
+	SponsorBlockMark       bool          `yaml:"sponsorblock_mark"`
+	SponsorBlockRemove     bool          `yaml:"sponsorblock_remove"`
+	SponsorBlockCategories []string      `yaml:"sponsorblock_categories"`
+	FFmpegPath             string        `yaml:"ffmpeg_path"`
+	YTDLPPath              string        `yaml:"yt_dlp_path"`
+	Cookies                string        `yaml:"cookies"`
+	CookiesFromBrowser     string        `yaml:"cookies_from_browser"`
+	Network                Network       `yaml:"network"`
+	MaxConcurrentDownloads int           `yaml:"max_concurrent_downloads"`
+	OutputTemplate         string        `yaml:"output_template"`
+	DownloadArchive        string        `yaml:"download_archive"`
+	RateLimit              string        `yaml:"rate_limit"`
+	RateSchedule           []RateWindow  `yaml:"rate_schedule"`
+	Hooks                  Hooks         `yaml:"hooks"`
+	Notifications          Notifications `yaml:"notifications"`
+	Profiles               []Profile     `yaml:"profiles"`
+}
+
+// Profile is a named set of download settings. Empty fields and unset
+// options fall back to the top-level config.
+type Profile struct {
+	Name               string   `yaml:"name"`
+	Format             string   `yaml:"format,omitempty"`
+	OutputDir          string   `yaml:"output_dir,omitempty"`
+	OutputTemplate     string   `yaml:"output_template,omitempty"`
+	EmbedSubtitles     *bool    `yaml:"embed_subtitles,omitempty"`
+	EmbedMetadata      *bool    `yaml:"embed_metadata,omitempty"`
+	EmbedChapters      *bool    `yaml:"embed_chapters,omitempty"`
+	EmbedThumbnail     *bool    `yaml:"embed_thumbnail,omitempty"`
+	SponsorBlockMark   *bool    `yaml:"sponsorblock_mark,omitempty"`
+	SponsorBlockRemove *bool    `yaml:"sponsorblock_remove,omitempty"`
+	AudioFormat        string   `yaml:"audio_format,omitempty"`
+	AudioQuality       string   `yaml:"audio_quality,omitempty"`
+	ExtraArgs          []string `yaml:"extra_args,omitempty"`
+}
+
+// Option returns the profile's setting for the download option stored in
+// the config field of that name, or nil when the profile leaves it alone.
+func (p Profile) Option(field string) *bool {
+	switch field {
+	case "EmbedSubtitles":
+		return p.EmbedSubtitles
+	case "EmbedMetadata":
+		return p.EmbedMetadata
+	case "EmbedChapters":
+		return p.EmbedChapters
+	case "EmbedThumbnail":
+		return p.EmbedThumbnail
+	case "SponsorBlockMark":
+		return p.SponsorBlockMark
+	case "SponsorBlockRemove":
+		return p.SponsorBlockRemove
+	}
+
+	return nil
+}
+
+// Option returns the configured default for the download option stored in
+// the config field of that name.
+func (c *Config) Option(field string) bool {
+	switch field {
+	case "EmbedSubtitles":
+		return c.EmbedSubtitles
+	case "EmbedMetadata":
+		return c.EmbedMetadata
+	case "EmbedChapters":
+		return c.EmbedChapters
+	case "EmbedThumbnail":
+		return c.EmbedThumbnail
+	case "SponsorBlockMark":
+		return c.SponsorBlockMark
+	case "SponsorBlockRemove":
+		return c.SponsorBlockRemove
+	}
+
+	return false
+}
+
+// SetOption stores the default for the download option in the config field
+// of that name. Unknown fields are ignored.
+func (c *Config) SetOption(field string, enabled bool) {
+	switch field {
+	case "EmbedSubtitles":
+		c.EmbedSubtitles = enabled
+	case "EmbedMetadata":
+		c.EmbedMetadata = enabled
+	case "EmbedChapters":
+		c.EmbedChapters = enabled
+	case "EmbedThumbnail":
+		c.EmbedThumbnail = enabled
+	case "SponsorBlockMark":
+		c.SponsorBlockMark = enabled
+	case "SponsorBlockRemove":
+		c.SponsorBlockRemove = enabled
+	}
+}
+
+// FindProfile looks up a profile by name, ignoring case.
+func (c *Config) FindProfile(name string) (Profile, bool) {
+	for _, profile := range c.Profiles {
+		if strings.EqualFold(profile.Name, strings.TrimSpace(name)) {
+			return profile, true
+		}
+	}
+
+	return Profile{}, false
+}
+
+// RateWindow overrides RateLimit between From and To (HH:MM, local time).
+// Windows may wrap around midnight. An empty Limit means full speed.
+type RateWindow struct {
+	From  string `yaml:"from"`
+	To    string `yaml:"to"`
+	Limit string `yaml:"limit"`
+}
+
+// Network holds the connection settings passed to every yt-dlp run. Sleep
+// values are in seconds; zero leaves the yt-dlp default in place.
+type Network struct {
+	Proxy            string  `yaml:"proxy"`
+	SourceAddress    string  `yaml:"source_address"`
+	ForceIPv4        bool    `yaml:"force_ipv4"`
+	ForceIPv6        bool    `yaml:"force_ipv6"`
+	SocketTimeout    float64 `yaml:"socket_timeout"`
+	SleepInterval    float64 `yaml:"sleep_interval"`
+	MaxSleepInterval float64 `yaml:"max_sleep_interval"`
+	SleepRequests    float64 `yaml:"sleep_requests"`
+}
+
+// Hooks are shell commands run after a download ends. Details about the
+// download are passed to them through XYTZ_* environment variables.
+type Hooks struct {
+	OnComplete string `yaml:"on_complete"`
+	OnError    string `yaml:"on_error"`
+	OnCancel   string `yaml:"on_cancel"`
+}
+
+// Notifications report finished and failed downloads outside the UI. OSC9
+// and OSC777 are terminal notifications, Command is run with the title and
+// message appended, and Progress shows the download percent in the
+// terminal tab or taskbar (OSC 9;4).
+type Notifications struct {
+	OSC9     bool   `yaml:"osc9"`
+	OSC777   bool   `yaml:"osc777"`
+	Bell     bool   `yaml:"bell"`
+	Command  string `yaml:"command"`
+	Progress bool   `yaml:"progress"`
 }
 
 func GetConfigDir() string {
@@ -98,6 +245,18 @@ func (c *Config) applyDefaults() {
 	if c.SortByDefault == "" {
 		c.SortByDefault = defaults.SortByDefault
 	}
+
+	if c.OutputTemplate == "" {
+		c.OutputTemplate = defaults.OutputTemplate
+	}
+
+	if c.MaxConcurrentDownloads <= 0 {
+		c.MaxConcurrentDownloads = defaults.MaxConcurrentDownloads
+	}
+
+	if c.SponsorBlockCategories == nil {
+		c.SponsorBlockCategories = defaults.SponsorBlockCategories
+	}
 }
 
 func (c *Config) ExpandPath(path string) string {
@@ -114,3 +273,50 @@ func (c *Config) ExpandPath(path string) string {
 func (c *Config) GetDownloadPath() string {
 	return c.ExpandPath(c.DefaultDownloadPath)
 }
+
+// RateLimitAt returns the --limit-rate value that applies at t, taking the
+// rate schedule into account. An empty string means no limit.
+func (c *Config) RateLimitAt(t time.Time) string {
+	minute := t.Hour()*60 + t.Minute()
+	for _, window := range c.RateSchedule {
+		from, err := parseClock(window.From)
+		if err != nil {
+			log.Printf("Warning: Invalid rate schedule time %q: %v", window.From, err)
+			continue
+		}
+
+		to, err := parseClock(window.To)
+		if err != nil {
+			log.Printf("Warning: Invalid rate schedule time %q: %v", window.To, err)
+			continue
+		}
+
+		inWindow := minute >= from && minute < to
+		if from > to {
+			inWindow = minute >= from || minute < to
+		}
+
+		if inWindow {
+			return window.Limit
+		}
+	}
+
+	return c.RateLimit
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (c *Config) GetArchivePath() string {
+	return c.ExpandPath(c.DownloadArchive)
+}
+
+func (c *Config) GetCookiesPath() string {
+	return c.ExpandPath(c.Cookies)
+}
@@ -2,13 +2,19 @@ package config
 
 func GetDefault() *Config {
 	return &Config{
-		SearchLimit:         25,
-		DefaultDownloadPath: "~/Videos",
-		DefaultFormat:       "bestvideo+bestaudio/best",
-		SortByDefault:       "relevance",
-		EmbedSubtitles:      false,
-		EmbedMetadata:       true,
-		EmbedChapters:       true,
+		SearchLimit:            25,
+		DefaultDownloadPath:    "~/Videos",
+		DefaultFormat:          "bestvideo+bestaudio/best",
+		SortByDefault:          "relevance",
+		EmbedSubtitles:         false,
+		EmbedMetadata:          true,
+		EmbedChapters:          true,
+		EmbedThumbnail:         false,
+		SponsorBlockMark:       false,
+		SponsorBlockRemove:     false,
+		SponsorBlockCategories: DefaultSponsorBlockCategories(),
+		MaxConcurrentDownloads: DefaultMaxConcurrentDownloads,
+		OutputTemplate:         DefaultOutputTemplate,
 	}
 }
 
@@ -25,3 +31,13 @@ const DefaultEmbedSubtitles = false
 const DefaultEmbedMetadata = true
 
 const DefaultEmbedChapters = true
+
+const DefaultEmbedThumbnail = false
+
+func DefaultSponsorBlockCategories() []string {
+	return []string{"sponsor", "selfpromo", "intro", "outro", "interaction"}
+}
+
+const DefaultMaxConcurrentDownloads = 3
+
+const DefaultOutputTemplate = "%(title)s.%(ext)s"
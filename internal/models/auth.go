@@ -0,0 +1,81 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/config"
+	"github.com/xdagiz/xytz/internal/styles"
+	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/utils"
+)
+
+type AuthModel struct {
+	Visible bool
+	Method  string
+	TestURL string
+	Testing bool
+	Result  string
+	Err     string
+}
+
+func (m *AuthModel) Show() {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.GetDefault()
+	}
+
+	m.Visible = true
+	m.Method = utils.AuthDescription(cfg)
+	m.TestURL = ""
+	m.Testing = false
+	m.Result = ""
+	m.Err = ""
+}
+
+func (m *AuthModel) Hide() {
+	m.Visible = false
+}
+
+func (m *AuthModel) StartTest(url string) {
+	m.TestURL = url
+	m.Testing = true
+	m.Result = ""
+	m.Err = ""
+}
+
+func (m *AuthModel) SetResult(msg types.AuthResultMsg) {
+	if msg.URL != m.TestURL {
+		return
+	}
+
+	m.Testing = false
+	m.Result = msg.Title
+	m.Err = msg.Err
+}
+
+func (m AuthModel) View() string {
+	if !m.Visible {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteRune('\n')
+	s.WriteString(styles.SortTitle.Render("Authentication"))
+	s.WriteRune('\n')
+	s.WriteString(m.Method)
+	s.WriteRune('\n')
+
+	switch {
+	case m.Testing:
+		s.WriteString(styles.MutedStyle.Render("Testing " + m.TestURL + "..."))
+	case m.Err != "":
+		s.WriteString(styles.ErrorMessageStyle.Render("✗ " + m.Err))
+	case m.Result != "":
+		s.WriteString(styles.CompletionMessageStyle.Render("✓ Access works: " + m.Result))
+	default:
+		s.WriteString(styles.SortHelp.Render("Run /auth <url> to test access to a video or playlist"))
+	}
+	s.WriteRune('\n')
+
+	return s.String()
+}
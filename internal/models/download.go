@@ -1,6 +1,7 @@
 package models
 
 import (
+	"cmp"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -12,20 +13,234 @@ import (
 	"github.com/xdagiz/xytz/internal/utils"
 
 	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
 )
 
-type DownloadModel struct {
-	Progress        progress.Model
-	SelectedVideo   types.VideoItem
-	CurrentSpeed    string
-	CurrentETA      string
+type PlaylistEntry struct {
+	Index   int
+	VideoID string
+	Title   string
+	Status  types.PlaylistItemStatus
+	Err     string
+}
+
+// streamProgress tracks one of the formats that make up a download, e.g. the
+// video and audio halves of "137+140".
+type streamProgress struct {
+	key        string
+	kind       string
+	downloaded float64
+	total      float64
+	percent    float64
+}
+
+type PostProcessStep struct {
+	Phase    types.DownloadPhase
+	Started  time.Time
+	Finished time.Time
+}
+
+func (s PostProcessStep) Duration() time.Duration {
+	if s.Finished.IsZero() {
+		return time.Since(s.Started)
+	}
+
+	return s.Finished.Sub(s.Started)
+}
+
+type DownloadJob struct {
+	ID              int
+	Video           types.VideoItem
+	URL             string
+	FormatID        string
+	OutputDir       string
+	Status          types.JobStatus
+	Percent         float64
+	Speed           string
+	ETA             string
 	Phase           string
-	Completed       bool
-	Paused          bool
-	Cancelled       bool
-	Destination     string
+	DownloadedBytes float64
+	TotalBytes      float64
+	FragmentIndex   int
+	FragmentCount   int
 	FileDestination string
+	RateLimit       string
+	RetryNote       string
+	Err             string
+	FilePaths       []string
+	SponsorSegments int
+	SponsorRemoved  float64
+	Playlist        []PlaylistEntry
+	PlaylistIndex   int
+	StreamSizes     map[string]float64
+	Steps           []PostProcessStep
+	itemTitles      map[string]string
+	streams         []streamProgress
+}
+
+func (j DownloadJob) FilePath() string {
+	if len(j.FilePaths) > 0 {
+		return j.FilePaths[len(j.FilePaths)-1]
+	}
+
+	return j.FileDestination
+}
+
+func (j *DownloadJob) playlistEntry(index, total int) *PlaylistEntry {
+	if index <= 0 {
+		return nil
+	}
+
+	for len(j.Playlist) < max(index, total) {
+		j.Playlist = append(j.Playlist, PlaylistEntry{Index: len(j.Playlist) + 1, Status: types.ItemPending})
+	}
+
+	return &j.Playlist[index-1]
+}
+
+func (j DownloadJob) playlistCount(status types.PlaylistItemStatus) int {
+	count := 0
+	for _, entry := range j.Playlist {
+		if entry.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// requestedStreams returns the format IDs merged by the job's format
+// selector, using the first alternative when the selector has fallbacks.
+func (j DownloadJob) requestedStreams() []string {
+	selector, _, _ := strings.Cut(j.FormatID, "/")
+	return strings.Split(selector, "+")
+}
+
+func (j *DownloadJob) trackStream(msg types.ProgressMsg) {
+	key := msg.FormatID
+	if key == "" {
+		key = msg.Destination
+	}
+	if key == "" || (msg.Percent == 0 && msg.DownloadedBytes == 0) {
+		return
+	}
+
+	idx := -1
+	for i := range j.streams {
+		if j.streams[i].key == key {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		j.streams = append(j.streams, streamProgress{key: key})
+		idx = len(j.streams) - 1
+	}
+
+	stream := &j.streams[idx]
+	stream.kind = strings.TrimPrefix(strings.TrimPrefix(msg.Status, "[download]"), " ")
+	stream.percent = msg.Percent
+	stream.downloaded = msg.DownloadedBytes
+	stream.total = msg.TotalBytes
+	if stream.total == 0 {
+		stream.total = j.StreamSizes[msg.FormatID]
+	}
+}
+
+func (j DownloadJob) streamCount() int {
+	return max(len(j.requestedStreams()), len(j.streams))
+}
+
+// OverallPercent combines the progress of every stream of a merged download,
+// weighted by stream size when all sizes are known and evenly otherwise.
+func (j DownloadJob) OverallPercent() float64 {
+	count := j.streamCount()
+	if count < 2 || len(j.streams) == 0 || len(j.Steps) > 0 {
+		return j.Percent
+	}
+
+	requested := j.requestedStreams()
+	last := len(j.streams) - 1
+
+	var done, total float64
+	weighted := true
+	for i := 0; i < count; i++ {
+		var size float64
+		if i < len(j.streams) {
+			size = j.streams[i].total
+		} else if i < len(requested) {
+			size = j.StreamSizes[requested[i]]
+		}
+		if size == 0 {
+			weighted = false
+			break
+		}
+
+		total += size
+		switch {
+		case i < last:
+			done += size
+		case i == last:
+			done += size * j.streams[i].percent / 100
+		}
+	}
+
+	if weighted && total > 0 {
+		return min(done/total*100, 100)
+	}
+
+	return (float64(last) + j.streams[last].percent/100) / float64(count) * 100
+}
+
+// StreamLabel describes the stream currently being downloaded, such as
+// "video 1/2". It is empty for single-stream downloads.
+func (j DownloadJob) StreamLabel() string {
+	count := j.streamCount()
+	if count < 2 || len(j.streams) == 0 {
+		return ""
+	}
+
+	last := len(j.streams) - 1
+	kind := j.streams[last].kind
+	if kind == "" {
+		kind = "stream"
+	}
+
+	return fmt.Sprintf("%s %d/%d", kind, last+1, count)
+}
+
+func (j *DownloadJob) startStep(phase types.DownloadPhase) {
+	now := time.Now()
+	if n := len(j.Steps); n > 0 {
+		if j.Steps[n-1].Phase == phase {
+			return
+		}
+		j.finishSteps(now)
+	}
+
+	j.Steps = append(j.Steps, PostProcessStep{Phase: phase, Started: now})
+}
+
+func (j *DownloadJob) finishSteps(now time.Time) {
+	if n := len(j.Steps); n > 0 && j.Steps[n-1].Finished.IsZero() {
+		j.Steps[n-1].Finished = now
+	}
+}
+
+func (j DownloadJob) Finished() bool {
+	return j.Status == types.JobCompleted || j.Status == types.JobFailed || j.Status == types.JobCancelled
+}
+
+type DownloadModel struct {
+	Progress         progress.Model
+	Jobs             []DownloadJob
+	ActiveIdx        int
+	Destination      string
+	RateInput        textinput.Model
+	RateInputVisible bool
+	RateErr          string
 }
 
 func NewDownloadModel() DownloadModel {
@@ -34,9 +249,17 @@ func NewDownloadModel() DownloadModel {
 	cfg, _ := config.Load()
 	destination := cfg.GetDownloadPath()
 
+	ti := textinput.New()
+	ti.Placeholder = "e.g. 500K or 2M, empty for full speed"
+	ti.Prompt = "❯ "
+	ti.PromptStyle = styles.FormatCustomInputPrompt
+	ti.PlaceholderStyle = ti.PlaceholderStyle.Foreground(styles.MutedColor)
+	ti.TextStyle = ti.TextStyle.Foreground(styles.SecondaryColor)
+
 	return DownloadModel{
 		Progress:    pr,
 		Destination: destination,
+		RateInput:   ti,
 	}
 }
 
@@ -46,39 +269,315 @@ func (m DownloadModel) Init() tea.Cmd {
 	})
 }
 
+func (m *DownloadModel) AddJob(id int, video types.VideoItem, url, formatID, outputDir string) tea.Cmd {
+	m.Jobs = append(m.Jobs, DownloadJob{
+		ID:        id,
+		Video:     video,
+		URL:       url,
+		FormatID:  formatID,
+		OutputDir: outputDir,
+		Status:    types.JobQueued,
+	})
+	m.ActiveIdx = len(m.Jobs) - 1
+
+	return m.Progress.SetPercent(0)
+}
+
+func (m *DownloadModel) AddPlaylistJob(id int, video types.VideoItem, url, formatID, outputDir string, items []types.VideoItem) tea.Cmd {
+	cmd := m.AddJob(id, video, url, formatID, outputDir)
+
+	job := m.ActiveJob()
+	job.itemTitles = make(map[string]string, len(items))
+	for i, item := range items {
+		job.itemTitles[item.ID] = item.Title()
+		job.Playlist = append(job.Playlist, PlaylistEntry{
+			Index:   i + 1,
+			VideoID: item.ID,
+			Title:   item.Title(),
+			Status:  types.ItemPending,
+		})
+	}
+
+	return cmd
+}
+
+func (m *DownloadModel) ActiveJob() *DownloadJob {
+	if m.ActiveIdx >= 0 && m.ActiveIdx < len(m.Jobs) {
+		return &m.Jobs[m.ActiveIdx]
+	}
+
+	return nil
+}
+
+func (m *DownloadModel) Job(id int) *DownloadJob {
+	for i := range m.Jobs {
+		if m.Jobs[i].ID == id {
+			return &m.Jobs[i]
+		}
+	}
+
+	return nil
+}
+
+func (m *DownloadModel) HasRunningJobs() bool {
+	for _, job := range m.Jobs {
+		if !job.Finished() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TerminalProgress averages the unfinished jobs into a single OSC 9;4 state
+// and percent. It reports paused only when every unfinished job is paused.
+func (m *DownloadModel) TerminalProgress() (int, int) {
+	var total float64
+	var count, paused int
+	for _, job := range m.Jobs {
+		if job.Finished() {
+			continue
+		}
+
+		count++
+		total += job.OverallPercent()
+		if job.Status == types.JobPaused {
+			paused++
+		}
+	}
+
+	if count == 0 {
+		return utils.ProgressClear, 0
+	}
+
+	state := utils.ProgressNormal
+	if paused == count {
+		state = utils.ProgressPaused
+	}
+
+	return state, int(total / float64(count))
+}
+
+func (m *DownloadModel) ClearFinished() tea.Cmd {
+	var jobs []DownloadJob
+	for _, job := range m.Jobs {
+		if !job.Finished() {
+			jobs = append(jobs, job)
+		}
+	}
+
+	m.Jobs = jobs
+	m.ActiveIdx = 0
+	if job := m.ActiveJob(); job != nil {
+		return m.Progress.SetPercent(job.OverallPercent() / 100.0)
+	}
+
+	return m.Progress.SetPercent(0)
+}
+
+func (m *DownloadModel) focus(idx int) tea.Cmd {
+	if len(m.Jobs) == 0 {
+		return nil
+	}
+
+	if idx < 0 {
+		idx = len(m.Jobs) - 1
+	} else if idx >= len(m.Jobs) {
+		idx = 0
+	}
+
+	m.ActiveIdx = idx
+	return m.Progress.SetPercent(m.Jobs[idx].OverallPercent() / 100.0)
+}
+
+func (m DownloadModel) updateRateInput(msg tea.KeyMsg) (DownloadModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.RateInputVisible = false
+		m.RateErr = ""
+		m.RateInput.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		limit := strings.TrimSpace(m.RateInput.Value())
+		if !utils.ValidRateLimit(limit) {
+			m.RateErr = "Invalid rate limit: " + limit
+			return m, nil
+		}
+
+		m.RateInputVisible = false
+		m.RateErr = ""
+		m.RateInput.Blur()
+		if job := m.ActiveJob(); job != nil && !job.Finished() {
+			return m, utils.SetRateLimit(job.ID, limit)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.RateInput, cmd = m.RateInput.Update(msg)
+	return m, cmd
+}
+
 func (m DownloadModel) Update(msg tea.Msg) (DownloadModel, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.RateInputVisible {
+		return m.updateRateInput(keyMsg)
+	}
+
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case types.DownloadStartedMsg:
+		if job := m.Job(msg.JobID); job != nil && !job.Finished() {
+			job.Status = types.JobRunning
+			job.RateLimit = msg.RateLimit
+			job.RetryNote = ""
+		}
+	case types.DownloadRetryMsg:
+		if job := m.Job(msg.JobID); job != nil && !job.Finished() {
+			job.RetryNote = fmt.Sprintf("%s. Retrying in %s (attempt %d/%d)", msg.Err, msg.Delay, msg.Attempt, msg.MaxAttempts)
+		}
+	case types.RateLimitMsg:
+		if job := m.Job(msg.JobID); job != nil {
+			job.RateLimit = msg.Limit
+		}
 	case types.ProgressMsg:
-		cmd = m.Progress.SetPercent(msg.Percent / 100.0)
-		m.CurrentSpeed = msg.Speed
-		m.CurrentETA = msg.Eta
-		m.Phase = msg.Status
+		job := m.Job(msg.JobID)
+		if job == nil {
+			break
+		}
+
+		if msg.Phase.IsPostProcessing() {
+			job.startStep(msg.Phase)
+		} else {
+			job.finishSteps(time.Now())
+			job.trackStream(msg)
+		}
+
+		job.Percent = msg.Percent
+		job.Speed = msg.Speed
+		job.ETA = msg.Eta
+		job.Phase = msg.Status
+		job.DownloadedBytes = msg.DownloadedBytes
+		job.TotalBytes = msg.TotalBytes
+		job.FragmentIndex = msg.FragmentIndex
+		job.FragmentCount = msg.FragmentCount
 		if msg.Destination != "" {
-			m.FileDestination = msg.Destination
+			job.FileDestination = msg.Destination
+			if entry := job.playlistEntry(job.PlaylistIndex, 0); entry != nil && entry.Title == "" {
+				base := filepath.Base(msg.Destination)
+				entry.Title = strings.TrimSuffix(base, filepath.Ext(base))
+			}
+		}
+
+		if active := m.ActiveJob(); active != nil && active.ID == msg.JobID {
+			cmd = m.Progress.SetPercent(job.OverallPercent() / 100.0)
+		}
+	case types.DownloadResultMsg:
+		job := m.Job(msg.JobID)
+		if job == nil || job.Status == types.JobCancelled {
+			break
+		}
+
+		if msg.Cancelled {
+			job.Status = types.JobCancelled
+		} else {
+			job.Status = types.JobFailed
+			job.Err = msg.Err
+		}
+		job.finishSteps(time.Now())
+	case types.DownloadFinishedMsg:
+		job := m.Job(msg.JobID)
+		if job == nil || job.Status == types.JobCancelled {
+			break
+		}
+
+		job.Status = types.JobCompleted
+		job.Percent = 100
+		job.FilePaths = msg.FilePaths
+		job.SponsorSegments = msg.SponsorBlockSegments
+		job.SponsorRemoved = msg.SponsorBlockRemoved
+		job.finishSteps(time.Now())
+	case types.PlaylistItemMsg:
+		job := m.Job(msg.JobID)
+		if job == nil {
+			break
+		}
+
+		entry := job.playlistEntry(msg.Index, msg.Total)
+		if entry == nil {
+			break
+		}
+
+		if msg.Status == types.ItemDownloading && msg.Index != job.PlaylistIndex {
+			job.streams = nil
+			job.Steps = nil
 		}
+
+		job.PlaylistIndex = msg.Index
+		if msg.VideoID != "" && msg.VideoID != entry.VideoID {
+			entry.VideoID = msg.VideoID
+			entry.Title = job.itemTitles[msg.VideoID]
+		}
+		entry.Status = msg.Status
+		entry.Err = msg.Err
 	case types.PauseDownloadMsg:
-		m.Paused = true
+		if job := m.Job(msg.JobID); job != nil && !job.Finished() {
+			job.Status = types.JobPaused
+		}
 	case types.ResumeDownloadMsg:
-		m.Paused = false
+		if job := m.Job(msg.JobID); job != nil && job.Status == types.JobPaused {
+			job.Status = types.JobRunning
+		}
 	case types.CancelDownloadMsg:
-		m.Cancelled = true
+		if job := m.Job(msg.JobID); job != nil && !job.Finished() {
+			job.Status = types.JobCancelled
+		}
 	case tea.KeyMsg:
-		if m.Completed || m.Cancelled && msg.Type == tea.KeyEnter {
+		switch msg.String() {
+		case "up", "k":
+			cmd = m.focus(m.ActiveIdx - 1)
+			return m, cmd
+		case "down", "j":
+			cmd = m.focus(m.ActiveIdx + 1)
+			return m, cmd
+		}
+
+		job := m.ActiveJob()
+		if job == nil {
+			break
+		}
+
+		if job.Finished() && msg.Type == tea.KeyEnter {
 			cmd = func() tea.Msg {
 				return types.DownloadCompleteMsg{}
 			}
 		}
-		if !m.Completed && !m.Cancelled {
+
+		if job.Status == types.JobCompleted && job.FilePath() != "" {
+			switch msg.String() {
+			case "o":
+				utils.OpenPath(job.FilePath())
+			case "f":
+				utils.OpenPath(filepath.Dir(job.FilePath()))
+			}
+		}
+
+		if !job.Finished() {
+			id := job.ID
 			switch msg.String() {
 			case "p", " ":
-				if m.Paused {
-					cmd = utils.ResumeDownload()
-				} else {
-					cmd = utils.PauseDownload()
+				if job.Status == types.JobPaused {
+					cmd = utils.ResumeDownload(id)
+				} else if job.Status == types.JobRunning {
+					cmd = utils.PauseDownload(id)
 				}
 			case "c", "esc":
-				cmd = utils.CancelDownload()
+				cmd = utils.CancelDownload(id)
+			case "r":
+				m.RateInputVisible = true
+				m.RateErr = ""
+				m.RateInput.SetValue(job.RateLimit)
+				m.RateInput.CursorEnd()
+				cmd = m.RateInput.Focus()
 			}
 		}
 	}
@@ -103,60 +602,248 @@ func (m DownloadModel) HandleResize(w, h int) DownloadModel {
 func (m DownloadModel) View() string {
 	var s strings.Builder
 
-	if m.SelectedVideo.ID != "" {
-		s.WriteString(styles.SectionHeaderStyle.Render(m.SelectedVideo.Title()))
+	job := m.ActiveJob()
+	if job == nil {
+		s.WriteString(styles.SectionHeaderStyle.Render("No downloads"))
+		s.WriteRune('\n')
+		return s.String()
+	}
+
+	if job.Video.ID != "" {
+		s.WriteString(styles.SectionHeaderStyle.Render(job.Video.Title()))
 		s.WriteRune('\n')
-		s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("⏱  %s", utils.FormatDuration(m.SelectedVideo.Duration))))
+		s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("⏱  %s", utils.FormatDuration(job.Video.Duration))))
 		s.WriteRune('\n')
-		s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("👁  %s views", utils.FormatNumber(m.SelectedVideo.Views))))
+		s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("👁  %s views", utils.FormatNumber(job.Video.Views))))
 		s.WriteRune('\n')
-		s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("📺 %s", m.SelectedVideo.Channel)))
+		s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("📺 %s", job.Video.Channel)))
+		s.WriteRune('\n')
+	} else if job.Video.Title() != "" {
+		s.WriteString(styles.SectionHeaderStyle.Render(job.Video.Title()))
 		s.WriteRune('\n')
 	}
 
 	statusText := "⇣ Downloading"
-	if m.Completed {
+	switch job.Status {
+	case types.JobQueued:
+		statusText = "… Queued"
+	case types.JobCompleted:
 		statusText = "✓ Download Complete"
-	} else if m.Paused {
+	case types.JobPaused:
 		statusText = "⏸ Paused"
-	} else if m.Cancelled {
+	case types.JobCancelled:
 		statusText = "✕ Cancelled"
-	} else if m.Phase != "" {
-		formatInfo := strings.TrimPrefix(m.Phase, "[download] ")
-		if formatInfo != "" && formatInfo != "[download]" {
-			statusText = "⇣ Downloading " + formatInfo
-		} else {
-			statusText = "⇣ Downloading"
+	case types.JobFailed:
+		statusText = "✕ Failed"
+	default:
+		if len(job.Steps) > 0 {
+			statusText = "⚙ " + job.Steps[len(job.Steps)-1].Phase.Label()
+		} else if label := job.StreamLabel(); label != "" {
+			statusText = "⇣ Downloading " + label
+		} else if job.Phase != "" {
+			formatInfo := strings.TrimPrefix(job.Phase, "[download] ")
+			if formatInfo != "" && formatInfo != "[download]" {
+				statusText = "⇣ Downloading " + formatInfo
+			}
 		}
 	}
 
 	s.WriteString(styles.SectionHeaderStyle.Render(statusText))
 	s.WriteRune('\n')
 
-	if m.Completed {
-		title := m.SelectedVideo.Title()
-		finalPath := filepath.Join(m.Destination, title+".mp4")
-		s.WriteString(styles.CompletionMessageStyle.Render("Video saved to " + finalPath))
+	if job.RetryNote != "" && !job.Finished() {
+		s.WriteString(styles.WarningMessageStyle.Render("⟳ " + job.RetryNote))
 		s.WriteRune('\n')
+	}
+
+	if len(job.Playlist) > 0 {
+		s.WriteString(m.playlistView(*job))
+	}
+
+	switch job.Status {
+	case types.JobCompleted:
+		s.WriteString(stepsView(job.Steps))
+		switch {
+		case len(job.FilePaths) > 1:
+			s.WriteString(styles.CompletionMessageStyle.Render(fmt.Sprintf("%d files saved to %s", len(job.FilePaths), filepath.Dir(job.FilePath()))))
+		case job.FilePath() != "":
+			s.WriteString(styles.CompletionMessageStyle.Render("Saved to " + job.FilePath()))
+		default:
+			s.WriteString(styles.CompletionMessageStyle.Render("Saved to " + cmp.Or(job.OutputDir, m.Destination)))
+		}
+		s.WriteRune('\n')
+		if job.SponsorSegments > 0 {
+			summary := fmt.Sprintf("✂ Removed %d SponsorBlock segments (%s)", job.SponsorSegments, utils.FormatDuration(job.SponsorRemoved))
+			s.WriteString(styles.MutedStyle.Render(summary))
+			s.WriteRune('\n')
+		}
 		s.WriteRune('\n')
 		s.WriteString(styles.HelpStyle.Render("Press Enter to continue"))
-	} else if m.Cancelled {
+		s.WriteRune('\n')
+	case types.JobCancelled:
 		s.WriteString(styles.ErrorMessageStyle.Render("Download was cancelled."))
 		s.WriteRune('\n')
-	} else {
+	case types.JobFailed:
+		s.WriteString(styles.ErrorMessageStyle.Render(job.Err))
+		s.WriteRune('\n')
+	default:
 		bar := styles.ProgressContainer.Render(m.Progress.View())
 		s.WriteString(bar)
 		s.WriteRune('\n')
 
-		s.WriteString("Speed: " + styles.SpeedStyle.Render(m.CurrentSpeed))
+		s.WriteString(stepsView(job.Steps))
+
+		s.WriteString("Speed: " + styles.SpeedStyle.Render(job.Speed))
+		s.WriteRune('\n')
+
+		s.WriteString("Time remaining: " + styles.TimeRemainingStyle.Render(job.ETA))
 		s.WriteRune('\n')
 
-		s.WriteString("Time remaining: " + styles.TimeRemainingStyle.Render(m.CurrentETA))
+		if job.TotalBytes > 0 && job.DownloadedBytes > 0 {
+			size := fmt.Sprintf("%s / %s", utils.FormatBytes(job.DownloadedBytes), utils.FormatBytes(job.TotalBytes))
+			s.WriteString("Size: " + styles.SpeedStyle.Render(size))
+			s.WriteRune('\n')
+		}
+
+		if job.FragmentCount > 0 {
+			fragments := fmt.Sprintf("%d/%d", job.FragmentIndex, job.FragmentCount)
+			s.WriteString("Fragments: " + styles.TimeRemainingStyle.Render(fragments))
+			s.WriteRune('\n')
+		}
+
+		rateLimit := job.RateLimit
+		if rateLimit == "" {
+			rateLimit = "full speed"
+		}
+		s.WriteString("Rate limit: " + styles.SpeedStyle.Render(rateLimit))
 		s.WriteRune('\n')
 
-		dest := m.Destination
+		dest := cmp.Or(job.OutputDir, m.Destination)
 		s.WriteString("Destination: " + styles.DestinationStyle.Render(dest))
 		s.WriteRune('\n')
+
+		if m.RateInputVisible {
+			s.WriteRune('\n')
+			s.WriteString(styles.SortTitle.Render("Change rate limit (restarts the download)"))
+			s.WriteRune('\n')
+			s.WriteString(styles.FormatCustomInputStyle.Render(m.RateInput.View()))
+			s.WriteRune('\n')
+			if m.RateErr != "" {
+				s.WriteString(styles.ErrorMessageStyle.Render(m.RateErr))
+				s.WriteRune('\n')
+			}
+		}
+	}
+
+	if len(m.Jobs) > 1 {
+		s.WriteString(m.queueView())
+	}
+
+	return s.String()
+}
+
+func stepsView(steps []PostProcessStep) string {
+	var s strings.Builder
+
+	for _, step := range steps {
+		duration := step.Duration().Round(100 * time.Millisecond)
+		if step.Finished.IsZero() {
+			s.WriteString(styles.TimeRemainingStyle.Render(fmt.Sprintf("› %s %s", step.Phase.Label(), duration)))
+		} else {
+			s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("✓ %s (%s)", step.Phase.Label(), duration)))
+		}
+		s.WriteRune('\n')
+	}
+
+	return s.String()
+}
+
+func (m DownloadModel) playlistView(job DownloadJob) string {
+	var s strings.Builder
+
+	if entry := job.playlistEntry(job.PlaylistIndex, 0); entry != nil {
+		title := entry.Title
+		if title == "" {
+			title = entry.VideoID
+		}
+		fmt.Fprintf(&s, "Item %d of %d: %s", entry.Index, len(job.Playlist), styles.SpinnerStyle.Render(title))
+		s.WriteRune('\n')
+	}
+
+	s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("✓ %d done • ✕ %d failed • ↷ %d skipped • %d total",
+		job.playlistCount(types.ItemDone),
+		job.playlistCount(types.ItemFailed),
+		job.playlistCount(types.ItemSkipped),
+		len(job.Playlist))))
+	s.WriteRune('\n')
+	s.WriteRune('\n')
+
+	const window = 8
+	start := max(0, job.PlaylistIndex-window/2)
+	end := min(len(job.Playlist), start+window)
+	start = max(0, end-window)
+
+	for _, entry := range job.Playlist[start:end] {
+		indicator := "○"
+		switch entry.Status {
+		case types.ItemDownloading:
+			indicator = "⇣"
+		case types.ItemDone:
+			indicator = "✓"
+		case types.ItemFailed:
+			indicator = "✕"
+		case types.ItemSkipped:
+			indicator = "↷"
+		}
+
+		title := entry.Title
+		if title == "" {
+			title = entry.VideoID
+		}
+		title = runewidth.Truncate(title, 60, "...")
+
+		line := fmt.Sprintf("%s %2d. %s", indicator, entry.Index, title)
+		switch entry.Status {
+		case types.ItemDownloading:
+			s.WriteString(styles.AutocompleteSelected.Render(line))
+		case types.ItemFailed:
+			s.WriteString(styles.AutocompleteItem.Foreground(styles.ErrorColor).Render(line))
+		case types.ItemPending:
+			s.WriteString(styles.AutocompleteItem.Foreground(styles.MutedColor).Render(line))
+		default:
+			s.WriteString(styles.AutocompleteItem.Render(line))
+		}
+		s.WriteRune('\n')
+	}
+
+	return s.String()
+}
+
+func (m DownloadModel) queueView() string {
+	var s strings.Builder
+
+	s.WriteString(styles.SectionHeaderStyle.Render(fmt.Sprintf("Downloads (%d)", len(m.Jobs))))
+	s.WriteRune('\n')
+
+	for i, job := range m.Jobs {
+		title := job.Video.Title()
+		if title == "" {
+			title = job.URL
+		}
+		title = runewidth.Truncate(title, 50, "...")
+
+		status := string(job.Status)
+		if job.Status == types.JobRunning || job.Status == types.JobPaused {
+			status = fmt.Sprintf("%s %.1f%%", status, job.OverallPercent())
+		}
+
+		line := fmt.Sprintf("%s  %s", title, styles.MutedStyle.Render(status))
+		if i == m.ActiveIdx {
+			s.WriteString(styles.AutocompleteSelected.Render("> " + line))
+		} else {
+			s.WriteString(styles.AutocompleteItem.Render("  " + line))
+		}
+		s.WriteRune('\n')
 	}
 
 	return s.String()
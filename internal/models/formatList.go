@@ -1,9 +1,13 @@
 package models
 
 import (
+	"cmp"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 
+	"github.com/xdagiz/xytz/internal/config"
 	"github.com/xdagiz/xytz/internal/styles"
 	"github.com/xdagiz/xytz/internal/types"
 	"github.com/xdagiz/xytz/internal/utils"
@@ -19,11 +23,33 @@ type FormatTab int
 const (
 	FormatTabVideo FormatTab = iota
 	FormatTabAudio
+	FormatTabSubtitles
 	FormatTabThumbnail
 	FormatTabCustom
 )
 
-var formatTabNames = []string{"Video", "Audio", "Thumbnail", "Custom"}
+var formatTabNames = []string{"Video", "Audio", "Subtitles", "Thumbnail", "Custom"}
+
+type markedSubtitle struct {
+	types.SubtitleTrack
+}
+
+func (i markedSubtitle) Title() string {
+	return "◉ " + i.SubtitleTrack.Title()
+}
+
+type formatDelegate struct {
+	list.DefaultDelegate
+	selectedSubs map[string]bool
+}
+
+func (d formatDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if track, ok := item.(types.SubtitleTrack); ok && d.selectedSubs[track.Key()] {
+		item = markedSubtitle{track}
+	}
+
+	d.DefaultDelegate.Render(w, m, index, item)
+}
 
 type FormatListModel struct {
 	Width            int
@@ -38,7 +64,22 @@ type FormatListModel struct {
 	VideoFormats     []list.Item
 	AudioFormats     []list.Item
 	ThumbnailFormats []list.Item
+	SubtitleTracks   []list.Item
 	AllFormats       []list.Item
+	FormatSizes      map[string]float64
+	DownloadPath     string
+	OutputTemplate   string
+	SectionInput     textinput.Model
+	SectionsVisible  bool
+	SectionErr       string
+	Sections         []types.Section
+	ForceKeyframes   bool
+	SelectedSubs     map[string]bool
+	SubtitleFormat   string
+	SubtitleMode     types.SubtitleMode
+	ThumbnailFormat  string
+	Profiles         ProfileModel
+	Profile          string
 }
 
 func NewFormatListModel() FormatListModel {
@@ -49,7 +90,8 @@ func NewFormatListModel() FormatListModel {
 	fd.Styles.SelectedDesc = styles.ListSelectedDescStyle
 	fd.Styles.DimmedTitle = styles.ListDimmedTitle
 	fd.Styles.DimmedDesc = styles.ListDimmedDesc
-	li := list.New([]list.Item{}, fd, 0, 0)
+	selectedSubs := make(map[string]bool)
+	li := list.New([]list.Item{}, formatDelegate{DefaultDelegate: fd, selectedSubs: selectedSubs}, 0, 0)
 	li.SetShowStatusBar(false)
 	li.SetShowTitle(false)
 	li.SetShowHelp(false)
@@ -65,11 +107,27 @@ func NewFormatListModel() FormatListModel {
 	ti.PlaceholderStyle = ti.PlaceholderStyle.Foreground(styles.MutedColor)
 	ti.TextStyle = ti.TextStyle.Foreground(styles.SecondaryColor)
 
+	si := textinput.New()
+	si.Placeholder = "12:30-15:10, 1:02:00-1:04:00, #3"
+	si.Prompt = "❯ "
+	si.PromptStyle = styles.FormatCustomInputPrompt
+	si.PlaceholderStyle = si.PlaceholderStyle.Foreground(styles.MutedColor)
+	si.TextStyle = si.TextStyle.Foreground(styles.SecondaryColor)
+
+	cfg, _ := config.Load()
+
 	return FormatListModel{
-		List:         li,
-		CustomInput:  ti,
-		Autocomplete: NewFormatAutocompleteModel(),
-		ActiveTab:    FormatTabVideo,
+		List:            li,
+		CustomInput:     ti,
+		SectionInput:    si,
+		SelectedSubs:    selectedSubs,
+		SubtitleFormat:  types.SubtitleFormats[0],
+		SubtitleMode:    types.SubtitleEmbed,
+		ThumbnailFormat: types.ThumbnailFormats[0],
+		Autocomplete:    NewFormatAutocompleteModel(),
+		ActiveTab:       FormatTabVideo,
+		DownloadPath:    cfg.GetDownloadPath(),
+		OutputTemplate:  cfg.OutputTemplate,
 	}
 }
 
@@ -91,14 +149,33 @@ func (m FormatListModel) View() string {
 		s.WriteRune('\n')
 	}
 
-	s.WriteString(styles.SectionHeaderStyle.Foreground(styles.MauveColor).Padding(1, 0).Render("Select a Format"))
+	s.WriteString(styles.SectionHeaderStyle.Foreground(styles.MauveColor).Padding(1, 0, 0).Render("Select a Format"))
+	s.WriteRune('\n')
+	s.WriteString(styles.MutedStyle.Render("Output: ") + styles.DestinationStyle.Italic(true).Render(m.OutputPreview()))
+	s.WriteRune('\n')
+	if m.Profile != "" {
+		s.WriteString(styles.MutedStyle.Render("Profile: ") + styles.DestinationStyle.Render(m.Profile))
+		s.WriteRune('\n')
+	}
+	if len(m.Sections) > 0 {
+		s.WriteString(styles.MutedStyle.Render("Sections: ") + styles.DestinationStyle.Render(m.sectionsSummary()))
+		s.WriteRune('\n')
+	}
+	if len(m.SelectedSubs) > 0 && m.ActiveTab != FormatTabSubtitles {
+		s.WriteString(styles.MutedStyle.Render("Subtitles: ") + styles.DestinationStyle.Render(m.subtitlesSummary()))
+		s.WriteRune('\n')
+	}
 	s.WriteRune('\n')
 
 	container := styles.FormatContainerStyle
 	s.WriteString(container.Render(m.renderTabs()))
 	s.WriteRune('\n')
 
-	if m.ActiveTab == FormatTabCustom {
+	if m.SectionsVisible {
+		s.WriteString(styles.CustomFormatContainerStyle.Render(m.sectionsView()))
+	} else if m.Profiles.Visible {
+		s.WriteString(styles.CustomFormatContainerStyle.Render(m.Profiles.View()))
+	} else if m.ActiveTab == FormatTabCustom {
 		s.WriteString(styles.CustomFormatContainerStyle.Render(styles.FormatCustomInputStyle.Render(m.CustomInput.View())))
 		s.WriteRune('\n')
 
@@ -110,12 +187,268 @@ func (m FormatListModel) View() string {
 			s.WriteString(styles.CustomFormatContainerStyle.Render(styles.FormatCustomHelpStyle.Render("Type to search formats.")))
 		}
 	} else {
+		switch m.ActiveTab {
+		case FormatTabSubtitles:
+			s.WriteString(container.Render(m.subtitleSettingsView()))
+			s.WriteRune('\n')
+		case FormatTabThumbnail:
+			s.WriteString(container.Render(styles.MutedStyle.Render(fmt.Sprintf("Save as: %s (c)", m.ThumbnailFormat))))
+			s.WriteRune('\n')
+		}
 		s.WriteString(container.Render(styles.ListContainer.Render(m.List.View())))
 	}
 
 	return s.String()
 }
 
+func (m FormatListModel) OutputPreview() string {
+	ext := "%(ext)s"
+	switch m.ActiveTab {
+	case FormatTabThumbnail:
+		ext = m.ThumbnailFormat
+	case FormatTabCustom:
+	default:
+		if format, ok := m.List.SelectedItem().(types.FormatItem); ok && format.Ext != "" {
+			ext = format.Ext
+		}
+	}
+
+	fields := utils.TemplateFields(m.SelectedVideo, ext)
+	tmpl := m.OutputTemplate
+	if len(m.Sections) > 0 {
+		tmpl = utils.SectionTemplate(tmpl)
+		fields["section_start"] = m.Sections[0].Start
+		fields["section_end"] = cmp.Or(m.Sections[0].End, m.SelectedVideo.Duration)
+	}
+
+	return utils.RenderTemplate(utils.OutputPath(m.DownloadPath, tmpl), fields)
+}
+
+func (m FormatListModel) subtitlesSummary() string {
+	var langs []string
+	for _, item := range m.SubtitleTracks {
+		if track, ok := item.(types.SubtitleTrack); ok && m.SelectedSubs[track.Key()] {
+			lang := track.Lang
+			if track.Auto {
+				lang += " (auto)"
+			}
+			langs = append(langs, lang)
+		}
+	}
+
+	return fmt.Sprintf("%s, %s, %s", strings.Join(langs, " "), m.SubtitleFormat, strings.ToLower(m.SubtitleMode.Label()))
+}
+
+func (m FormatListModel) subtitleSettingsView() string {
+	if len(m.SubtitleTracks) == 0 {
+		return styles.FormatCustomHelpStyle.Render("This video has no subtitles.")
+	}
+
+	settings := fmt.Sprintf("Mode: %s (m) • Format: %s (c) • %d selected (space)", m.SubtitleMode.Label(), m.SubtitleFormat, len(m.SelectedSubs))
+	return styles.MutedStyle.Render(settings)
+}
+
+// subtitleRequest returns the current subtitle selection, or nil when no
+// track is selected.
+func (m FormatListModel) subtitleRequest() *types.SubtitleRequest {
+	if len(m.SelectedSubs) == 0 {
+		return nil
+	}
+
+	req := &types.SubtitleRequest{Format: m.SubtitleFormat, Mode: m.SubtitleMode}
+	for _, item := range m.SubtitleTracks {
+		track, ok := item.(types.SubtitleTrack)
+		if !ok || !m.SelectedSubs[track.Key()] {
+			continue
+		}
+
+		if track.Auto {
+			req.AutoLanguages = append(req.AutoLanguages, track.Lang)
+		} else {
+			req.Languages = append(req.Languages, track.Lang)
+		}
+	}
+
+	return req
+}
+
+func (m FormatListModel) toggleSubtitle() {
+	track, ok := m.List.SelectedItem().(types.SubtitleTrack)
+	if !ok {
+		return
+	}
+
+	if m.SelectedSubs[track.Key()] {
+		delete(m.SelectedSubs, track.Key())
+	} else {
+		m.SelectedSubs[track.Key()] = true
+	}
+}
+
+func (m FormatListModel) updateSubtitles(msg tea.KeyMsg) (FormatListModel, tea.Cmd, bool) {
+	if m.List.FilterState() == list.Filtering {
+		return m, nil, false
+	}
+
+	switch msg.String() {
+	case " ":
+		m.toggleSubtitle()
+		return m, nil, true
+	case "m":
+		m.SubtitleMode = m.SubtitleMode.Next()
+		return m, nil, true
+	case "c":
+		i := slices.Index(types.SubtitleFormats, m.SubtitleFormat)
+		m.SubtitleFormat = types.SubtitleFormats[(i+1)%len(types.SubtitleFormats)]
+		return m, nil, true
+	case "enter":
+		if len(m.List.Items()) == 0 {
+			return m, nil, true
+		}
+
+		if len(m.SelectedSubs) == 0 {
+			m.toggleSubtitle()
+		}
+
+		if m.SubtitleMode != types.SubtitleOnly {
+			m.ActiveTab = FormatTabVideo
+			m.updateListForTab()
+			return m, nil, true
+		}
+
+		cmd := func() tea.Msg {
+			return types.StartDownloadMsg{
+				URL:             m.URL,
+				FormatID:        config.DefaultFormat,
+				DownloadOptions: m.DownloadOptions,
+				Subtitles:       m.subtitleRequest(),
+			}
+		}
+		return m, cmd, true
+	}
+
+	return m, nil, false
+}
+
+func (m FormatListModel) updateThumbnails(msg tea.KeyMsg) (FormatListModel, tea.Cmd, bool) {
+	if m.List.FilterState() == list.Filtering {
+		return m, nil, false
+	}
+
+	switch msg.String() {
+	case "c":
+		i := slices.Index(types.ThumbnailFormats, m.ThumbnailFormat)
+		m.ThumbnailFormat = types.ThumbnailFormats[(i+1)%len(types.ThumbnailFormats)]
+		return m, nil, true
+	case "enter":
+		thumb, ok := m.List.SelectedItem().(types.ThumbnailItem)
+		if !ok {
+			return m, nil, true
+		}
+
+		cmd := func() tea.Msg {
+			return types.StartDownloadMsg{
+				URL:             m.URL,
+				FormatID:        "thumbnail",
+				DownloadOptions: m.DownloadOptions,
+				Thumbnail:       &types.ThumbnailRequest{URL: thumb.URL, Format: m.ThumbnailFormat},
+			}
+		}
+		return m, cmd, true
+	}
+
+	return m, nil, false
+}
+
+func (m FormatListModel) sectionsSummary() string {
+	var parts []string
+	for _, section := range m.Sections {
+		end := "end"
+		if section.End > 0 {
+			end = utils.FormatDuration(section.End)
+		}
+		parts = append(parts, utils.FormatDuration(section.Start)+"-"+end)
+	}
+
+	summary := strings.Join(parts, ", ")
+	if m.ForceKeyframes {
+		summary += " (precise cuts)"
+	}
+
+	return summary
+}
+
+const maxChapterLines = 8
+
+func (m FormatListModel) sectionsView() string {
+	var s strings.Builder
+
+	s.WriteString(styles.FormatCustomInputStyle.Render(m.SectionInput.View()))
+	s.WriteRune('\n')
+
+	if m.SectionErr != "" {
+		s.WriteString(styles.ErrorMessageStyle.Render(m.SectionErr))
+		s.WriteRune('\n')
+	}
+
+	indicator := "○"
+	if m.ForceKeyframes {
+		indicator = "◉"
+	}
+	fmt.Fprintf(&s, "%s Precise cuts, re-encodes around the cut points (Ctrl+t)", styles.SortItem.Render(indicator))
+	s.WriteRune('\n')
+
+	if chapters := m.SelectedVideo.Chapters; len(chapters) > 0 {
+		s.WriteRune('\n')
+		s.WriteString(styles.SortTitle.Render("Chapters"))
+		s.WriteRune('\n')
+		for i, chapter := range chapters {
+			if i == maxChapterLines {
+				s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("… %d more", len(chapters)-i)))
+				s.WriteRune('\n')
+				break
+			}
+
+			line := fmt.Sprintf("#%-3d %s-%s  %s", i+1, utils.FormatDuration(chapter.StartTime), utils.FormatDuration(chapter.EndTime), chapter.Title)
+			s.WriteString(styles.MutedStyle.Render(line))
+			s.WriteRune('\n')
+		}
+	}
+
+	s.WriteString(styles.FormatCustomHelpStyle.Render("Enter to apply, an empty list downloads the whole video. Esc to close."))
+
+	return s.String()
+}
+
+func (m FormatListModel) updateSectionInput(msg tea.KeyMsg) (FormatListModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.SectionsVisible = false
+		m.SectionErr = ""
+		m.SectionInput.Blur()
+		return m, nil
+	case tea.KeyCtrlT:
+		m.ForceKeyframes = !m.ForceKeyframes
+		return m, nil
+	case tea.KeyEnter:
+		sections, err := utils.ParseSections(m.SectionInput.Value(), m.SelectedVideo.Duration, m.SelectedVideo.Chapters)
+		if err != nil {
+			m.SectionErr = err.Error()
+			return m, nil
+		}
+
+		m.Sections = sections
+		m.SectionsVisible = false
+		m.SectionErr = ""
+		m.SectionInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.SectionInput, cmd = m.SectionInput.Update(msg)
+	return m, cmd
+}
+
 func (m FormatListModel) renderTabs() string {
 	var tabBar strings.Builder
 
@@ -140,7 +473,7 @@ func (m FormatListModel) renderTabs() string {
 func (m FormatListModel) HandleResize(w, h int) FormatListModel {
 	m.Width = w
 	m.Height = h
-	m.List.SetSize(w, h-14)
+	m.List.SetSize(w, m.listHeight())
 	m.CustomInput.Width = w - 12
 	m.Autocomplete.HandleResize(w, h)
 	return m
@@ -149,6 +482,28 @@ func (m FormatListModel) HandleResize(w, h int) FormatListModel {
 func (m FormatListModel) Update(msg tea.Msg) (FormatListModel, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.SectionsVisible {
+		return m.updateSectionInput(keyMsg)
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.Profiles.Visible {
+		m.Profiles, cmd = m.Profiles.Update(keyMsg)
+		return m, cmd
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		var handled bool
+		switch m.ActiveTab {
+		case FormatTabSubtitles:
+			m, cmd, handled = m.updateSubtitles(keyMsg)
+		case FormatTabThumbnail:
+			m, cmd, handled = m.updateThumbnails(keyMsg)
+		}
+		if handled {
+			return m, cmd
+		}
+	}
+
 	handled, autocompleteCmd := m.Autocomplete.Update(msg)
 	if handled {
 		if keyMsg, ok := msg.(tea.KeyMsg); ok {
@@ -188,6 +543,13 @@ func (m FormatListModel) Update(msg tea.Msg) (FormatListModel, tea.Cmd) {
 		case key.Matches(msg, formatTabPrev):
 			m.prevTab()
 			return m, nil
+		case key.Matches(msg, formatSections) && m.ActiveTab != FormatTabCustom && m.List.FilterState() != list.Filtering:
+			m.SectionsVisible = true
+			m.SectionErr = ""
+			return m, m.SectionInput.Focus()
+		case key.Matches(msg, formatProfiles) && m.ActiveTab != FormatTabCustom && m.List.FilterState() != list.Filtering:
+			m.Profiles.Show(m.Profile, true)
+			return m, nil
 		}
 		switch msg.Type {
 		case tea.KeyEnter:
@@ -199,6 +561,10 @@ func (m FormatListModel) Update(msg tea.Msg) (FormatListModel, tea.Cmd) {
 							URL:             m.URL,
 							FormatID:        formatID,
 							DownloadOptions: m.DownloadOptions,
+							StreamSizes:     m.FormatSizes,
+							Sections:        m.Sections,
+							ForceKeyframes:  m.ForceKeyframes,
+							Subtitles:       m.subtitleRequest(),
 						}
 					}
 				}
@@ -230,6 +596,15 @@ func (m FormatListModel) Update(msg tea.Msg) (FormatListModel, tea.Cmd) {
 					URL:             m.URL,
 					FormatID:        format.FormatValue,
 					DownloadOptions: m.DownloadOptions,
+					StreamSizes:     m.FormatSizes,
+					Sections:        m.Sections,
+					ForceKeyframes:  m.ForceKeyframes,
+					Subtitles:       m.subtitleRequest(),
+					EstimatedBytes:  format.Bytes,
+				}
+				if format.Audio != nil {
+					msg.AudioFormat = format.Audio.Codec
+					msg.AudioQuality = format.Audio.Quality
 				}
 				return msg
 			}
@@ -273,12 +648,26 @@ func (m *FormatListModel) prevTab() {
 	m.updateListForTab()
 }
 
+// listHeight leaves room for the settings line shown above the list in the
+// Subtitles and Thumbnail tabs.
+func (m FormatListModel) listHeight() int {
+	if m.ActiveTab == FormatTabSubtitles || m.ActiveTab == FormatTabThumbnail {
+		return max(m.Height-17, 0)
+	}
+
+	return max(m.Height-15, 0)
+}
+
 func (m *FormatListModel) updateListForTab() {
+	m.List.SetHeight(m.listHeight())
+
 	switch m.ActiveTab {
 	case FormatTabVideo:
 		m.List.SetItems(m.VideoFormats)
 	case FormatTabAudio:
 		m.List.SetItems(m.AudioFormats)
+	case FormatTabSubtitles:
+		m.List.SetItems(m.SubtitleTracks)
 	case FormatTabThumbnail:
 		m.List.SetItems(m.ThumbnailFormats)
 	case FormatTabCustom:
@@ -288,10 +677,45 @@ func (m *FormatListModel) updateListForTab() {
 	m.List.ResetSelected()
 }
 
-func (m *FormatListModel) SetFormats(videoFormats, audioFormats, thumbnailFormats, allFormats []list.Item) {
+// SetProfile updates the output preview for the active profile.
+func (m *FormatListModel) SetProfile(cfg *config.Config, profile *config.Profile) {
+	m.Profile = ""
+	m.DownloadPath = cfg.GetDownloadPath()
+	m.OutputTemplate = cfg.OutputTemplate
+	if profile == nil {
+		return
+	}
+
+	m.Profile = profile.Name
+	if profile.OutputDir != "" {
+		m.DownloadPath = cfg.ExpandPath(profile.OutputDir)
+	}
+	if profile.OutputTemplate != "" {
+		m.OutputTemplate = profile.OutputTemplate
+	}
+}
+
+// ProfileDownload starts a download of the current video with the format of
+// the active profile, keeping the sections and subtitles picked here.
+func (m FormatListModel) ProfileDownload(format string) tea.Cmd {
+	return func() tea.Msg {
+		return types.StartDownloadMsg{
+			URL:             m.URL,
+			FormatID:        format,
+			DownloadOptions: m.DownloadOptions,
+			StreamSizes:     m.FormatSizes,
+			Sections:        m.Sections,
+			ForceKeyframes:  m.ForceKeyframes,
+			Subtitles:       m.subtitleRequest(),
+		}
+	}
+}
+
+func (m *FormatListModel) SetFormats(videoFormats, audioFormats, thumbnailFormats, subtitleTracks, allFormats []list.Item) {
 	m.VideoFormats = videoFormats
 	m.AudioFormats = audioFormats
 	m.ThumbnailFormats = thumbnailFormats
+	m.SubtitleTracks = subtitleTracks
 	m.AllFormats = allFormats
 	m.updateListForTab()
 }
@@ -306,8 +730,17 @@ func (m *FormatListModel) ResetTab() {
 	m.ActiveTab = FormatTabVideo
 	m.CustomInput.SetValue("")
 	m.Autocomplete.Hide()
+	m.SectionInput.SetValue("")
+	m.SectionsVisible = false
+	m.Profiles.Hide()
+	m.SectionErr = ""
+	m.Sections = nil
+	m.ForceKeyframes = false
+	clear(m.SelectedSubs)
 	m.updateListForTab()
 }
 
 var formatTabNext = key.NewBinding(key.WithKeys("tab"))
 var formatTabPrev = key.NewBinding(key.WithKeys("shift+tab"))
+var formatSections = key.NewBinding(key.WithKeys("s"))
+var formatProfiles = key.NewBinding(key.WithKeys("p"))
@@ -50,13 +50,22 @@ func NewHelpModel() HelpModel {
 				Title: "commands",
 				Content: ` /channel <username>      Search videos from a channel
  /playlist <url or id>    Search video for a playlist
+ /get <url>               Download a URL with the default format
  /resume                  Resume unfinished downloads
+ /queue                   Show active and queued downloads
+ /downloads               Browse completed downloads
+ /auth [url]              Show cookie setup, test it against a URL
+ /profile [name]          Pick a download profile
  /help                    Show this help message`,
 			},
 			{
 				Title: "navigation",
 				Content: ` ↑ / ctrl+p    Previous search in history
  ↓ / ctrl+n    Next search in history
+ space         Select video in results
+ a             Select all visible videos
+ d             Quick download with the default format
+ P             Download the entire playlist (in /playlist)
  b             Go back`,
 			},
 			{
@@ -64,6 +73,7 @@ func NewHelpModel() HelpModel {
 				Content: ` - Search for a video or paste URL
  - Select a video from results to choose format
  - Choose a download format and start download
+ - Select several videos with space and press Enter to queue them
  - Press ctrl+c to quit anytime`,
 			},
 		},
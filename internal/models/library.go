@@ -0,0 +1,207 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/styles"
+	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/utils"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type libraryItem struct {
+	utils.CompletedDownload
+	missing bool
+}
+
+func (i libraryItem) Title() string {
+	return cmp.Or(i.CompletedDownload.Title, filepath.Base(i.FilePath))
+}
+
+func (i libraryItem) Description() string {
+	parts := []string{}
+	if i.Channel != "" {
+		parts = append(parts, i.Channel)
+	}
+	if i.FormatID != "" {
+		parts = append(parts, i.FormatID)
+	}
+	if i.Size > 0 {
+		parts = append(parts, utils.FormatBytes(float64(i.Size)))
+	}
+	parts = append(parts, i.Timestamp.Local().Format("2006-01-02 15:04"))
+	if i.missing {
+		parts = append(parts, "✗ file missing")
+	}
+
+	return strings.Join(parts, " • ")
+}
+
+func (i libraryItem) FilterValue() string {
+	return i.Title() + " " + i.Channel + " " + i.URL + " " + i.FilePath
+}
+
+// LibraryModel lists the completed downloads recorded in the index.
+type LibraryModel struct {
+	Width         int
+	Height        int
+	List          list.Model
+	ConfirmDelete *utils.CompletedDownload
+	Err           string
+}
+
+func NewLibraryModel() LibraryModel {
+	ld := list.NewDefaultDelegate()
+	ld.Styles.NormalTitle = styles.ListTitleStyle
+	ld.Styles.SelectedTitle = styles.ListSelectedTitleStyle
+	ld.Styles.NormalDesc = styles.ListDescStyle
+	ld.Styles.SelectedDesc = styles.ListSelectedDescStyle
+	ld.Styles.DimmedTitle = styles.ListDimmedTitle
+	ld.Styles.DimmedDesc = styles.ListDimmedDesc
+	li := list.New([]list.Item{}, ld, 0, 0)
+	li.SetShowStatusBar(false)
+	li.SetShowTitle(false)
+	li.SetShowHelp(false)
+	li.KeyMap.Quit.SetKeys("q")
+	li.KeyMap.NextPage.SetKeys("right", "l", "pgdown")
+	li.KeyMap.PrevPage.SetKeys("left", "h", "pgup")
+	li.FilterInput.Cursor.Style = li.FilterInput.Cursor.Style.Foreground(styles.MauveColor)
+	li.FilterInput.PromptStyle = li.FilterInput.PromptStyle.Foreground(styles.SecondaryColor)
+
+	return LibraryModel{List: li}
+}
+
+// Load reads the index, newest downloads first.
+func (m *LibraryModel) Load() {
+	m.ConfirmDelete = nil
+	m.Err = ""
+
+	downloads, err := utils.LoadCompleted()
+	if err != nil {
+		log.Printf("Failed to load completed downloads: %v", err)
+		m.Err = fmt.Sprintf("Could not read the download index: %v", err)
+	}
+
+	slices.SortStableFunc(downloads, func(a, b utils.CompletedDownload) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+
+	items := make([]list.Item, len(downloads))
+	for i, download := range downloads {
+		_, statErr := os.Stat(download.FilePath)
+		items[i] = libraryItem{CompletedDownload: download, missing: statErr != nil}
+	}
+
+	m.List.SetItems(items)
+}
+
+func (m LibraryModel) HandleResize(w, h int) LibraryModel {
+	m.Width = w
+	m.Height = h
+	m.List.SetSize(w, h-7)
+	return m
+}
+
+func (m LibraryModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(styles.SectionHeaderStyle.Render(fmt.Sprintf("Downloads (%d)", len(m.List.Items()))))
+	s.WriteRune('\n')
+
+	switch {
+	case m.ConfirmDelete != nil:
+		s.WriteString(styles.ErrorMessageStyle.Render(fmt.Sprintf("Delete %s from disk? (y/n)", m.ConfirmDelete.FilePath)))
+		s.WriteRune('\n')
+	case m.Err != "":
+		s.WriteString(styles.ErrorMessageStyle.Render(m.Err))
+		s.WriteRune('\n')
+	}
+
+	if len(m.List.Items()) == 0 {
+		s.WriteString(styles.MutedStyle.Render("No completed downloads yet."))
+		return s.String()
+	}
+
+	s.WriteString(styles.ListContainer.Render(m.List.View()))
+
+	return s.String()
+}
+
+func (m LibraryModel) selected() (libraryItem, bool) {
+	item, ok := m.List.SelectedItem().(libraryItem)
+	return item, ok
+}
+
+func (m LibraryModel) Update(msg tea.Msg) (LibraryModel, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.ConfirmDelete != nil {
+		if keyMsg.String() == "y" {
+			if err := utils.DeleteCompleted(*m.ConfirmDelete); err != nil {
+				m.ConfirmDelete = nil
+				m.Err = fmt.Sprintf("Delete failed: %v", err)
+				return m, nil
+			}
+			m.Load()
+			return m, nil
+		}
+
+		m.ConfirmDelete = nil
+		return m, nil
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && !m.List.SettingFilter() {
+		item, ok := m.selected()
+		if !ok {
+			var cmd tea.Cmd
+			m.List, cmd = m.List.Update(msg)
+			return m, cmd
+		}
+
+		switch keyMsg.String() {
+		case "enter", "o":
+			if item.missing {
+				m.Err = "File not found: " + item.FilePath
+				return m, nil
+			}
+			m.Err = ""
+			utils.OpenPath(item.FilePath)
+			return m, nil
+		case "f":
+			m.Err = ""
+			utils.OpenPath(filepath.Dir(item.FilePath))
+			return m, nil
+		case "r":
+			if strings.Contains(item.URL, "/playlist?list=") {
+				m.Err = "Playlist entries cannot be re-downloaded from here, open the playlist instead"
+				return m, nil
+			}
+			m.Err = ""
+			url := item.URL
+			return m, func() tea.Msg {
+				return types.StartFormatMsg{URL: url}
+			}
+		case "x":
+			if err := utils.RemoveCompleted(item.CompletedDownload); err != nil {
+				m.Err = fmt.Sprintf("Remove failed: %v", err)
+				return m, nil
+			}
+			m.Load()
+			return m, nil
+		case "D":
+			download := item.CompletedDownload
+			m.ConfirmDelete = &download
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.List, cmd = m.List.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/styles"
+	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const maxDuplicateLines = 5
+
+// PreflightModel asks what to do when the destination of a download is low
+// on space or already has the file. It is shared by every screen a download
+// can be started from.
+type PreflightModel struct {
+	Visible bool
+	Result  types.PreflightMsg
+}
+
+func (m *PreflightModel) Show(result types.PreflightMsg) {
+	m.Visible = true
+	m.Result = result
+}
+
+func (m *PreflightModel) Hide() {
+	m.Visible = false
+	m.Result = types.PreflightMsg{}
+}
+
+func (m PreflightModel) View() string {
+	p := m.Result
+	var s strings.Builder
+
+	if p.LowSpace() {
+		s.WriteString(styles.SortTitle.Render("Not enough disk space"))
+		s.WriteRune('\n')
+		s.WriteString(styles.ErrorMessageStyle.Render(fmt.Sprintf("Needs about %s, %s free in %s", utils.FormatBytes(p.EstimatedBytes), utils.FormatBytes(p.FreeBytes), p.OutputDir)))
+		s.WriteString("\n\n")
+	}
+
+	if p.Err != "" {
+		s.WriteString(styles.ErrorMessageStyle.Render(p.Err))
+		s.WriteString("\n\n")
+	}
+
+	if len(p.Duplicates) == 0 {
+		s.WriteString(styles.FormatCustomHelpStyle.Render("Enter download anyway • Esc cancel"))
+		return s.String()
+	}
+
+	s.WriteString(styles.SortTitle.Render("Already in the download folder"))
+	s.WriteRune('\n')
+	for i, path := range p.Duplicates {
+		if i == maxDuplicateLines {
+			s.WriteString(styles.MutedStyle.Render(fmt.Sprintf("… %d more", len(p.Duplicates)-i)))
+			s.WriteRune('\n')
+			break
+		}
+		s.WriteString(styles.MutedStyle.Render(path))
+		s.WriteRune('\n')
+	}
+	s.WriteRune('\n')
+
+	if m.batch() {
+		s.WriteString(styles.FormatCustomHelpStyle.Render("o overwrite • s skip these • Esc cancel"))
+	} else {
+		s.WriteString(styles.FormatCustomHelpStyle.Render("o overwrite • k keep both • s/Esc skip"))
+	}
+
+	return s.String()
+}
+
+// batch reports whether the prompt is for several videos, where skipping
+// drops the ones already there and downloads the rest.
+func (m PreflightModel) batch() bool {
+	return len(m.Result.Start.URLs) > 0
+}
+
+func (m PreflightModel) Update(msg tea.KeyMsg) (PreflightModel, tea.Cmd) {
+	start := m.Result.Start
+	start.Checked = true
+	duplicates := len(m.Result.Duplicates) > 0
+
+	switch msg.String() {
+	case "esc":
+		m.Hide()
+		return m, nil
+	case "enter":
+		if duplicates {
+			return m, nil
+		}
+	case "o":
+		if !duplicates {
+			return m, nil
+		}
+		start.Overwrite = true
+	case "s":
+		if !duplicates {
+			return m, nil
+		}
+		if !m.batch() {
+			m.Hide()
+			return m, nil
+		}
+		start.URLs, start.Videos = nil, nil
+		for i, url := range m.Result.Start.URLs {
+			if slices.Contains(m.Result.DuplicateURLs, url) {
+				start.Skipped++
+				continue
+			}
+			start.URLs = append(start.URLs, url)
+			if i < len(m.Result.Start.Videos) {
+				start.Videos = append(start.Videos, m.Result.Start.Videos[i])
+			}
+		}
+	case "k":
+		if !duplicates || m.Result.KeepBoth == "" {
+			return m, nil
+		}
+		start.OutputDir = m.Result.OutputDir
+		start.OutputTemplate = m.Result.KeepBoth
+	default:
+		return m, nil
+	}
+
+	m.Hide()
+	return m, func() tea.Msg {
+		return start
+	}
+}
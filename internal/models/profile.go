@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/config"
+	"github.com/xdagiz/xytz/internal/styles"
+	"github.com/xdagiz/xytz/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ProfileModel is the profile picker shared by the search and format
+// screens. The first entry clears the active profile.
+type ProfileModel struct {
+	Visible  bool
+	Profiles []config.Profile
+	Cursor   int
+	Download bool
+}
+
+func (m *ProfileModel) Show(active string, download bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.GetDefault()
+	}
+
+	m.Visible = true
+	m.Profiles = cfg.Profiles
+	m.Download = download
+	m.Cursor = 0
+	for i, profile := range m.Profiles {
+		if strings.EqualFold(profile.Name, active) {
+			m.Cursor = i + 1
+		}
+	}
+}
+
+func (m *ProfileModel) Hide() {
+	m.Visible = false
+}
+
+func (m ProfileModel) Update(msg tea.KeyMsg) (ProfileModel, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k", "ctrl+p":
+		m.Cursor--
+		if m.Cursor < 0 {
+			m.Cursor = len(m.Profiles)
+		}
+	case "down", "j", "ctrl+n":
+		m.Cursor++
+		if m.Cursor > len(m.Profiles) {
+			m.Cursor = 0
+		}
+	case "esc", "b":
+		m.Hide()
+	case "enter":
+		m.Hide()
+		selected := types.ProfileSelectedMsg{Download: m.Download}
+		if m.Cursor > 0 {
+			selected.Name = m.Profiles[m.Cursor-1].Name
+		}
+
+		return m, func() tea.Msg {
+			return selected
+		}
+	}
+
+	return m, nil
+}
+
+func (m ProfileModel) View() string {
+	var s strings.Builder
+	s.WriteString(styles.SortTitle.Render("Profiles"))
+	s.WriteRune('\n')
+
+	names := []string{"No profile"}
+	for _, profile := range m.Profiles {
+		names = append(names, fmt.Sprintf("%-16s %s", profile.Name, styles.MutedStyle.Render(profileSummary(profile))))
+	}
+
+	for i, name := range names {
+		if i == m.Cursor {
+			s.WriteString(styles.AutocompleteSelected.Render("> " + name))
+		} else {
+			s.WriteString(styles.AutocompleteItem.Render("  " + name))
+		}
+		s.WriteRune('\n')
+	}
+
+	if len(m.Profiles) == 0 {
+		s.WriteString(styles.SortHelp.Render("Add profiles to your config to see them here"))
+		s.WriteRune('\n')
+	} else if m.Download {
+		s.WriteString(styles.SortHelp.Render("Enter downloads with the profile's format, Esc to close"))
+		s.WriteRune('\n')
+	}
+
+	return s.String()
+}
+
+func profileSummary(profile config.Profile) string {
+	var parts []string
+	if profile.Format != "" {
+		parts = append(parts, profile.Format)
+	}
+
+	if profile.AudioFormat != "" {
+		parts = append(parts, "audio "+strings.TrimSpace(profile.AudioFormat+" "+profile.AudioQuality))
+	}
+
+	if profile.OutputDir != "" {
+		parts = append(parts, profile.OutputDir)
+	}
+
+	return strings.Join(parts, " • ")
+}
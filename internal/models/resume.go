@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/xdagiz/xytz/internal/styles"
@@ -320,6 +321,13 @@ func (m *ResumeModel) View(width, height int) string {
 			titleStyle = styles.AutocompleteItem.Render("  " + title)
 		}
 
+		if size := item.PartialSize(); size > 0 {
+			url += " • " + utils.FormatBytes(float64(size)) + " on disk"
+			if percent := item.EstimatedPercent(); percent >= 0 {
+				url += fmt.Sprintf(" (~%.0f%%)", percent)
+			}
+		}
+
 		urlStyle := styles.AutocompleteItem.Foreground(styles.MutedColor).Render("  " + url)
 
 		b.WriteRune('\n')
@@ -3,8 +3,6 @@ package models
 import (
 	"fmt"
 	"log"
-	"os/exec"
-	"runtime"
 	"strings"
 
 	"github.com/xdagiz/xytz/internal/config"
@@ -26,11 +24,14 @@ type SearchModel struct {
 	Autocomplete    SlashModel
 	ResumeList      ResumeModel
 	Help            HelpModel
+	Auth            AuthModel
+	Profiles        ProfileModel
 	History         []string
 	HistoryIndex    int
 	OriginalQuery   string
 	SortBy          types.SortBy
 	DownloadOptions []types.DownloadOption
+	Profile         string
 	HasFFmpeg       bool
 }
 
@@ -54,14 +55,7 @@ func NewSearchModel() SearchModel {
 
 	options := types.DownloadOptions()
 	for i := range options {
-		switch options[i].ConfigField {
-		case "EmbedSubtitles":
-			options[i].Enabled = cfg.EmbedSubtitles
-		case "EmbedMetadata":
-			options[i].Enabled = cfg.EmbedMetadata
-		case "EmbedChapters":
-			options[i].Enabled = cfg.EmbedChapters
-		}
+		options[i].Enabled = cfg.Option(options[i].ConfigField)
 	}
 
 	return SearchModel{
@@ -110,6 +104,11 @@ func (m SearchModel) View() string {
 			s.WriteString("\n")
 			s.WriteString(resumeView)
 		}
+	} else if m.Auth.Visible {
+		s.WriteString(m.Auth.View())
+	} else if m.Profiles.Visible {
+		s.WriteRune('\n')
+		s.WriteString(m.Profiles.View())
 	} else if m.Help.Visible {
 		helpView := m.Help.View()
 		if helpView != "" {
@@ -125,6 +124,9 @@ func (m SearchModel) View() string {
 		s.WriteString(currentSort)
 		s.WriteRune('\n')
 		s.WriteString(styles.SortTitle.Render("Download Options"))
+		if m.Profile != "" {
+			s.WriteString(styles.SortHelp.Render("(profile: " + m.Profile + ")"))
+		}
 		s.WriteRune('\n')
 
 		for _, opt := range m.DownloadOptions {
@@ -222,6 +224,12 @@ func (m SearchModel) Update(msg tea.Msg) (SearchModel, tea.Cmd) {
 		return m, nil
 	}
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.Profiles.Visible {
+		var cmd tea.Cmd
+		m.Profiles, cmd = m.Profiles.Update(keyMsg)
+		return m, cmd
+	}
+
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
 		switch keyMsg.Type {
 		case tea.KeyEsc:
@@ -229,6 +237,10 @@ func (m SearchModel) Update(msg tea.Msg) (SearchModel, tea.Cmd) {
 				m.ResumeList.Hide()
 				return m, nil
 			}
+			if m.Auth.Visible {
+				m.Auth.Hide()
+				return m, nil
+			}
 			m.Help.Hide()
 		}
 	}
@@ -272,11 +284,7 @@ func (m SearchModel) Update(msg tea.Msg) (SearchModel, tea.Cmd) {
 				if item := m.ResumeList.SelectedItem(); item != nil {
 					m.ResumeList.Hide()
 					cmd = func() tea.Msg {
-						return types.StartResumeDownloadMsg{
-							URL:      item.URL,
-							FormatID: item.FormatID,
-							Title:    item.Title,
-						}
+						return types.StartResumeDownloadMsg{Request: item.Request()}
 					}
 
 					return m, cmd
@@ -324,7 +332,7 @@ func (m SearchModel) Update(msg tea.Msg) (SearchModel, tea.Cmd) {
 		case tea.KeyShiftTab:
 			m.SortBy = m.SortBy.Prev()
 			return m, nil
-		case tea.KeyCtrlS, tea.KeyCtrlJ, tea.KeyCtrlL:
+		case tea.KeyCtrlS, tea.KeyCtrlJ, tea.KeyCtrlL, tea.KeyCtrlT, tea.KeyCtrlG, tea.KeyCtrlX:
 			for i := range m.DownloadOptions {
 				if m.DownloadOptions[i].KeyBinding == msg.Type {
 					if m.DownloadOptions[i].RequiresFFmpeg && !m.HasFFmpeg {
@@ -392,9 +400,46 @@ func (m *SearchModel) executeSlashCommand(slashCmd, query, args string) tea.Cmd
 				return types.StartPlaylistURLMsg{Query: args}
 			}
 		}
+	case "get":
+		if args == "" {
+			m.Input.SetValue("/get ")
+			m.Input.CursorEnd()
+		} else {
+			m.addToHistory(query)
+			m.Input.SetValue("")
+			cmd = func() tea.Msg {
+				return types.QuickDownloadMsg{URL: args}
+			}
+		}
 	case "resume":
 		m.ResumeList.Show()
 		m.Input.SetValue("")
+	case "queue":
+		m.Input.SetValue("")
+		cmd = func() tea.Msg {
+			return types.ShowDownloadsMsg{}
+		}
+	case "downloads":
+		m.Input.SetValue("")
+		cmd = func() tea.Msg {
+			return types.ShowLibraryMsg{}
+		}
+	case "auth":
+		m.Auth.Show()
+		m.Input.SetValue("")
+		if args != "" {
+			m.Auth.StartTest(args)
+			cmd = utils.TestAuth(args)
+		}
+	case "profile":
+		m.Input.SetValue("")
+		if args == "" {
+			m.Profiles.Show(m.Profile, false)
+		} else {
+			cmd = func() tea.Msg {
+				return types.ProfileSelectedMsg{Name: args}
+			}
+		}
 	case "help":
 		m.Help.Toggle()
 		m.Input.SetValue("")
@@ -403,6 +448,26 @@ func (m *SearchModel) executeSlashCommand(slashCmd, query, args string) tea.Cmd
 	return cmd
 }
 
+// SetProfile makes profile the active one and sets the download options to
+// the profile's, falling back to the config for options it leaves unset. A
+// nil profile goes back to the config defaults.
+func (m *SearchModel) SetProfile(cfg *config.Config, profile *config.Profile) {
+	m.Profile = ""
+	if profile != nil {
+		m.Profile = profile.Name
+	}
+
+	for i := range m.DownloadOptions {
+		field := m.DownloadOptions[i].ConfigField
+		m.DownloadOptions[i].Enabled = cfg.Option(field)
+		if profile != nil {
+			if enabled := profile.Option(field); enabled != nil {
+				m.DownloadOptions[i].Enabled = *enabled
+			}
+		}
+	}
+}
+
 func (m *SearchModel) updateAutocompleteFilter() {
 	if !m.Autocomplete.Visible {
 		return
@@ -438,25 +503,17 @@ func keyTypeToString(key tea.KeyType) string {
 		return "Ctrl+j"
 	case tea.KeyCtrlL:
 		return "Ctrl+l"
+	case tea.KeyCtrlT:
+		return "Ctrl+t"
+	case tea.KeyCtrlG:
+		return "Ctrl+g"
+	case tea.KeyCtrlX:
+		return "Ctrl+x"
 	default:
 		return ""
 	}
 }
 
 func openGithub() {
-	go func() {
-		var cmd *exec.Cmd
-		switch runtime.GOOS {
-		case "windows":
-			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", types.GithubRepoLink)
-		case "darwin":
-			cmd = exec.Command("open", types.GithubRepoLink)
-		default:
-			cmd = exec.Command("xdg-open", types.GithubRepoLink)
-		}
-
-		if err := cmd.Start(); err != nil {
-			log.Printf("Failed to open URL: %v", err)
-		}
-	}()
+	utils.OpenPath(types.GithubRepoLink)
 }
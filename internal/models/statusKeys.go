@@ -9,19 +9,30 @@ import (
 )
 
 type StatusKeys struct {
-	Quit   key.Binding
-	Back   key.Binding
-	Enter  key.Binding
-	Pause  key.Binding
-	Cancel key.Binding
-	Tab    key.Binding
-	Help   key.Binding
-	Up     key.Binding
-	Down   key.Binding
-	Select key.Binding
-	Delete key.Binding
-	Next   key.Binding
-	Prev   key.Binding
+	Quit       key.Binding
+	Back       key.Binding
+	Enter      key.Binding
+	Pause      key.Binding
+	Cancel     key.Binding
+	Tab        key.Binding
+	Help       key.Binding
+	Up         key.Binding
+	Down       key.Binding
+	Select     key.Binding
+	Delete     key.Binding
+	Next       key.Binding
+	Prev       key.Binding
+	SelectAll  key.Binding
+	Playlist   key.Binding
+	Quick      key.Binding
+	Open       key.Binding
+	Folder     key.Binding
+	RateLimit  key.Binding
+	Sections   key.Binding
+	Profiles   key.Binding
+	Filter     key.Binding
+	Redownload key.Binding
+	Remove     key.Binding
 }
 
 func GetStatusKeys(state types.State, helpVisible bool, resumeVisible bool, resumeKeys ResumeKeyMap) StatusKeys {
@@ -49,11 +60,64 @@ func GetStatusKeys(state types.State, helpVisible bool, resumeVisible bool, resu
 			key.WithKeys("esc", "b"),
 			key.WithHelp("Esc/b", "back"),
 		)
+		keys.Select = key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "select"),
+		)
+		keys.SelectAll = key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "select all"),
+		)
+		keys.Playlist = key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "download playlist"),
+		)
+		keys.Quick = key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "quick download"),
+		)
 	case types.StateFormatList:
 		keys.Back = key.NewBinding(
 			key.WithKeys("esc", "b"),
 			key.WithHelp("Esc/b", "back"),
 		)
+		keys.Sections = key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sections"),
+		)
+		keys.Profiles = key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "profile"),
+		)
+	case types.StateLibrary:
+		keys.Back = key.NewBinding(
+			key.WithKeys("esc", "b"),
+			key.WithHelp("Esc/b", "back"),
+		)
+		keys.Filter = key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
+		)
+		keys.Open = key.NewBinding(
+			key.WithKeys("enter", "o"),
+			key.WithHelp("o", "open file"),
+		)
+		keys.Folder = key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "open folder"),
+		)
+		keys.Redownload = key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "re-download"),
+		)
+		keys.Remove = key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "remove entry"),
+		)
+		keys.Delete = key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "delete file"),
+		)
 	case types.StateDownload:
 		keys.Back = key.NewBinding(
 			key.WithKeys("b"),
@@ -71,6 +135,26 @@ func GetStatusKeys(state types.State, helpVisible bool, resumeVisible bool, resu
 			key.WithKeys("esc", "c"),
 			key.WithHelp("Esc/c", "cancel"),
 		)
+		keys.RateLimit = key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "rate limit"),
+		)
+		keys.Open = key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open file"),
+		)
+		keys.Folder = key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "open folder"),
+		)
+		keys.Up = key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "prev job"),
+		)
+		keys.Down = key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "next job"),
+		)
 	}
 
 	return keys
@@ -121,6 +205,17 @@ func FormatKeysForStatusBar(keys StatusKeys) string {
 	addKey(keys.Delete)
 	addKey(keys.Next)
 	addKey(keys.Prev)
+	addKey(keys.SelectAll)
+	addKey(keys.Playlist)
+	addKey(keys.Quick)
+	addKey(keys.Open)
+	addKey(keys.Folder)
+	addKey(keys.RateLimit)
+	addKey(keys.Sections)
+	addKey(keys.Profiles)
+	addKey(keys.Filter)
+	addKey(keys.Redownload)
+	addKey(keys.Remove)
 
 	return strings.Join(parts, " • ")
 }
@@ -2,10 +2,15 @@ package models
 
 import (
 	"fmt"
+	"io"
+	"log"
+	"maps"
 	"strings"
 
+	"github.com/xdagiz/xytz/internal/config"
 	"github.com/xdagiz/xytz/internal/styles"
 	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/utils"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -23,9 +28,55 @@ type VideoListModel struct {
 	PlaylistName     string
 	PlaylistURL      string
 	ErrMsg           string
+	Selected         map[string]bool
+	Archived         map[string]bool
+	ArchivePath      string
+	PolicyVisible    bool
+	PolicyIdx        int
+	Policies         []types.FormatPolicy
+	PolicyPlaylist   bool
+}
+
+type markedVideo struct {
+	types.VideoItem
+	selected bool
+	archived bool
+}
+
+func (i markedVideo) Title() string {
+	if i.selected {
+		return "◉ " + i.VideoTitle
+	}
+
+	return i.VideoTitle
+}
+
+func (i markedVideo) Description() string {
+	if i.archived {
+		return i.Desc + " • ✓ downloaded"
+	}
+
+	return i.Desc
+}
+
+type videoDelegate struct {
+	list.DefaultDelegate
+	selected map[string]bool
+	archived map[string]bool
+}
+
+func (d videoDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if video, ok := item.(types.VideoItem); ok && (d.selected[video.ID] || d.archived[video.ID]) {
+		item = markedVideo{video, d.selected[video.ID], d.archived[video.ID]}
+	}
+
+	d.DefaultDelegate.Render(w, m, index, item)
 }
 
 func NewVideoListModel() VideoListModel {
+	selected := make(map[string]bool)
+	archived := make(map[string]bool)
+
 	vd := list.NewDefaultDelegate()
 	vd.Styles.NormalTitle = styles.ListTitleStyle
 	vd.Styles.SelectedTitle = styles.ListSelectedTitleStyle
@@ -33,14 +84,17 @@ func NewVideoListModel() VideoListModel {
 	vd.Styles.SelectedDesc = styles.ListSelectedDescStyle
 	vd.Styles.DimmedTitle = styles.ListDimmedTitle
 	vd.Styles.DimmedDesc = styles.ListDimmedDesc
-	li := list.New([]list.Item{}, vd, 0, 0)
+	li := list.New([]list.Item{}, videoDelegate{DefaultDelegate: vd, selected: selected, archived: archived}, 0, 0)
 	li.SetShowStatusBar(false)
 	li.SetShowTitle(false)
 	li.SetShowHelp(false)
 	li.KeyMap.Quit.SetKeys("q")
+	li.KeyMap.NextPage.SetKeys("right", "l", "pgdown", "f")
 	li.FilterInput.Cursor.Style = li.FilterInput.Cursor.Style.Foreground(styles.MauveColor)
 	li.FilterInput.PromptStyle = li.FilterInput.PromptStyle.Foreground(styles.SecondaryColor)
 
+	cfg, _ := config.Load()
+
 	return VideoListModel{
 		List:             li,
 		IsChannelSearch:  false,
@@ -49,6 +103,10 @@ func NewVideoListModel() VideoListModel {
 		PlaylistName:     "",
 		PlaylistURL:      "",
 		ErrMsg:           "",
+		Selected:         selected,
+		Archived:         archived,
+		ArchivePath:      cfg.GetArchivePath(),
+		Policies:         types.FormatPolicies(cfg.DefaultFormat),
 	}
 }
 
@@ -83,13 +141,46 @@ func (m VideoListModel) View() string {
 		headerText = fmt.Sprintf("Search Results for: %s", m.CurrentQuery)
 		headerStyle = styles.SectionHeaderStyle
 	}
+
+	if len(m.Selected) > 0 {
+		headerText += styles.MutedStyle.Render(fmt.Sprintf("  (%d selected)", len(m.Selected)))
+	}
+
 	s.WriteString(headerStyle.Render(headerText))
 	s.WriteRune('\n')
+
+	if m.PolicyVisible {
+		s.WriteString(m.policyView())
+		return s.String()
+	}
+
 	s.WriteString(styles.ListContainer.Render(m.List.View()))
 
 	return s.String()
 }
 
+func (m VideoListModel) policyView() string {
+	var s strings.Builder
+
+	if m.PolicyPlaylist {
+		s.WriteString(styles.SortTitle.Render("Download entire playlist as"))
+	} else {
+		s.WriteString(styles.SortTitle.Render(fmt.Sprintf("Download %d videos as", len(m.Selected))))
+	}
+	s.WriteRune('\n')
+
+	for i, policy := range m.Policies {
+		if i == m.PolicyIdx {
+			s.WriteString(styles.AutocompleteSelected.Render("> " + policy.Name))
+		} else {
+			s.WriteString(styles.AutocompleteItem.Render("  " + policy.Name))
+		}
+		s.WriteRune('\n')
+	}
+
+	return s.String()
+}
+
 func (m VideoListModel) HandleResize(w, h int) VideoListModel {
 	m.Width = w
 	m.Height = h
@@ -97,8 +188,211 @@ func (m VideoListModel) HandleResize(w, h int) VideoListModel {
 	return m
 }
 
+func (m VideoListModel) videoURL(video types.VideoItem) string {
+	if m.IsPlaylistSearch && m.PlaylistURL != "" {
+		playlistID := ""
+		if strings.Contains(m.PlaylistURL, "list=") {
+			parts := strings.Split(m.PlaylistURL, "list=")
+			if len(parts) > 1 {
+				playlistID = parts[1]
+				if idx := strings.Index(playlistID, "&"); idx != -1 {
+					playlistID = playlistID[:idx]
+				}
+			}
+		}
+
+		if playlistID != "" {
+			return fmt.Sprintf("https://www.youtube.com/watch?v=%s&list=%s", video.ID, playlistID)
+		}
+	}
+
+	return "https://www.youtube.com/watch?v=" + video.ID
+}
+
+func (m *VideoListModel) ClearSelection() {
+	clear(m.Selected)
+	m.PolicyVisible = false
+	m.PolicyPlaylist = false
+	m.PolicyIdx = 0
+}
+
+// RefreshArchive reloads the download archive. The map is refilled in place
+// because the list delegate holds a reference to it.
+func (m *VideoListModel) RefreshArchive() {
+	ids, err := utils.LoadArchive(m.ArchivePath)
+	if err != nil {
+		log.Printf("Failed to load download archive: %v", err)
+		return
+	}
+
+	clear(m.Archived)
+	maps.Copy(m.Archived, ids)
+}
+
+func (m *VideoListModel) toggleAllVisible() {
+	visible := m.List.VisibleItems()
+
+	allSelected := len(visible) > 0
+	for _, item := range visible {
+		if video, ok := item.(types.VideoItem); ok && !m.Selected[video.ID] {
+			allSelected = false
+			break
+		}
+	}
+
+	for _, item := range visible {
+		if video, ok := item.(types.VideoItem); ok {
+			if allSelected {
+				delete(m.Selected, video.ID)
+			} else {
+				m.Selected[video.ID] = true
+			}
+		}
+	}
+}
+
+func (m VideoListModel) selectedVideos() []types.VideoItem {
+	var videos []types.VideoItem
+	for _, item := range m.List.Items() {
+		if video, ok := item.(types.VideoItem); ok && m.Selected[video.ID] {
+			videos = append(videos, video)
+		}
+	}
+
+	return videos
+}
+
+func (m VideoListModel) updatePolicy(msg tea.KeyMsg) (VideoListModel, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k", "ctrl+p":
+		m.PolicyIdx--
+		if m.PolicyIdx < 0 {
+			m.PolicyIdx = len(m.Policies) - 1
+		}
+	case "down", "j", "ctrl+n":
+		m.PolicyIdx++
+		if m.PolicyIdx >= len(m.Policies) {
+			m.PolicyIdx = 0
+		}
+	case "esc", "b":
+		m.PolicyVisible = false
+		m.PolicyPlaylist = false
+	case "enter":
+		policy := m.Policies[m.PolicyIdx]
+		if m.PolicyPlaylist {
+			var videos []types.VideoItem
+			for _, item := range m.List.Items() {
+				if video, ok := item.(types.VideoItem); ok {
+					videos = append(videos, video)
+				}
+			}
+
+			url := m.PlaylistURL
+			m.ClearSelection()
+			return m, func() tea.Msg {
+				return types.StartDownloadMsg{
+					URL:      url,
+					FormatID: policy.Format,
+					Videos:   videos,
+					Playlist: true,
+				}
+			}
+		}
+
+		var videos []types.VideoItem
+		var urls []string
+		skipped := 0
+		for _, video := range m.selectedVideos() {
+			if m.Archived[video.ID] {
+				skipped++
+				continue
+			}
+			videos = append(videos, video)
+			urls = append(urls, "https://www.youtube.com/watch?v="+video.ID)
+		}
+
+		m.ClearSelection()
+		return m, func() tea.Msg {
+			return types.StartDownloadMsg{
+				FormatID: policy.Format,
+				URLs:     urls,
+				Videos:   videos,
+				Skipped:  skipped,
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// quickDownload downloads the selected videos, or the highlighted one when
+// nothing is selected, without asking for a format.
+func (m *VideoListModel) quickDownload() tea.Cmd {
+	videos := m.selectedVideos()
+	if len(m.Selected) == 0 {
+		video, ok := m.List.SelectedItem().(types.VideoItem)
+		if !ok {
+			return nil
+		}
+		videos = []types.VideoItem{video}
+	}
+
+	msg := types.QuickDownloadMsg{}
+	for _, video := range videos {
+		if m.Archived[video.ID] {
+			msg.Skipped++
+			continue
+		}
+		msg.Videos = append(msg.Videos, video)
+		msg.URLs = append(msg.URLs, "https://www.youtube.com/watch?v="+video.ID)
+	}
+
+	m.ClearSelection()
+	return func() tea.Msg {
+		return msg
+	}
+}
+
 func (m VideoListModel) Update(msg tea.Msg) (VideoListModel, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.PolicyVisible {
+		return m.updatePolicy(keyMsg)
+	}
+
 	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.List.SettingFilter() {
+			break
+		}
+
+		switch msg.String() {
+		case " ":
+			if video, ok := m.List.SelectedItem().(types.VideoItem); ok {
+				if m.Selected[video.ID] {
+					delete(m.Selected, video.ID)
+				} else {
+					m.Selected[video.ID] = true
+				}
+			}
+			return m, nil
+		case "a":
+			m.toggleAllVisible()
+			return m, nil
+		case "d":
+			if m.ErrMsg != "" || len(m.List.Items()) == 0 {
+				return m, nil
+			}
+			return m, m.quickDownload()
+		case "P":
+			if m.IsPlaylistSearch && m.PlaylistURL != "" && m.ErrMsg == "" {
+				m.PolicyVisible = true
+				m.PolicyPlaylist = true
+				m.PolicyIdx = 0
+			}
+			return m, nil
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
@@ -113,28 +407,12 @@ func (m VideoListModel) Update(msg tea.Msg) (VideoListModel, tea.Cmd) {
 				}
 			} else if len(m.List.Items()) == 0 {
 				return m, nil
+			} else if len(m.Selected) > 0 {
+				m.PolicyVisible = true
+				m.PolicyIdx = 0
+				return m, nil
 			} else if video, ok := m.List.SelectedItem().(types.VideoItem); ok {
-				var url string
-				if m.IsPlaylistSearch && m.PlaylistURL != "" {
-					playlistID := ""
-					if strings.Contains(m.PlaylistURL, "list=") {
-						parts := strings.Split(m.PlaylistURL, "list=")
-						if len(parts) > 1 {
-							playlistID = parts[1]
-							if idx := strings.Index(playlistID, "&"); idx != -1 {
-								playlistID = playlistID[:idx]
-							}
-						}
-					}
-
-					if playlistID != "" {
-						url = fmt.Sprintf("https://www.youtube.com/watch?v=%s&list=%s", video.ID, playlistID)
-					} else {
-						url = "https://www.youtube.com/watch?v=" + video.ID
-					}
-				} else {
-					url = "https://www.youtube.com/watch?v=" + video.ID
-				}
+				url := m.videoURL(video)
 				cmd = func() tea.Msg {
 					return types.StartFormatMsg{URL: url, SelectedVideo: video}
 				}
@@ -26,12 +26,42 @@ var AllCommands = []Command{
 		Usage:       "/playlist <id>",
 		HasArg:      true,
 	},
+	{
+		Name:        "get",
+		Description: "Download a URL right away with the default format",
+		Usage:       "/get <url>",
+		HasArg:      true,
+	},
 	{
 		Name:        "resume",
 		Description: "Resume unfinished download",
 		Usage:       "/resume",
 		HasArg:      false,
 	},
+	{
+		Name:        "queue",
+		Description: "Show active and queued downloads",
+		Usage:       "/queue",
+		HasArg:      false,
+	},
+	{
+		Name:        "downloads",
+		Description: "Browse completed downloads",
+		Usage:       "/downloads",
+		HasArg:      false,
+	},
+	{
+		Name:        "auth",
+		Description: "Show the cookie setup and test it against a URL",
+		Usage:       "/auth [url]",
+		HasArg:      false,
+	},
+	{
+		Name:        "profile",
+		Description: "Pick a download profile, or switch to one by name",
+		Usage:       "/profile [name]",
+		HasArg:      false,
+	},
 	{
 		Name:        "help",
 		Description: "Show available commands",
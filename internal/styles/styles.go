@@ -52,6 +52,7 @@ var (
 	CompletionMessageStyle = lipgloss.NewStyle().Foreground(SuccessColor)
 	HelpStyle              = lipgloss.NewStyle().Foreground(MutedColor).Faint(true)
 	ErrorMessageStyle      = lipgloss.NewStyle().Foreground(ErrorColor)
+	WarningMessageStyle    = lipgloss.NewStyle().Foreground(WarningColor)
 
 	autocompleteStyle = lipgloss.NewStyle().PaddingLeft(1)
 	AutocompleteItem  = autocompleteStyle.
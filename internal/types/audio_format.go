@@ -0,0 +1,48 @@
+package types
+
+import "fmt"
+
+// AudioPreset is an "extract audio" choice in the Audio tab. Codec and
+// Quality map to yt-dlp's --audio-format and --audio-quality; an empty
+// Quality keeps yt-dlp's default, which is what lossless codecs want.
+type AudioPreset struct {
+	Codec   string
+	Quality string
+	Bitrate int
+}
+
+func (p AudioPreset) Name() string {
+	if p.Lossless() {
+		return p.Codec + " (lossless)"
+	}
+
+	return fmt.Sprintf("%s @%dk", p.Codec, p.Bitrate)
+}
+
+func (p AudioPreset) Lossless() bool {
+	return p.Codec == "flac" || p.Codec == "wav"
+}
+
+// EstimatedBytes guesses the size of the extracted file from the bitrate. It
+// returns 0 when the size cannot be guessed, as for flac.
+func (p AudioPreset) EstimatedBytes(duration float64) float64 {
+	if p.Bitrate == 0 || duration <= 0 {
+		return 0
+	}
+
+	return float64(p.Bitrate) * 1000 / 8 * duration
+}
+
+func AudioPresets() []AudioPreset {
+	return []AudioPreset{
+		{Codec: "mp3", Quality: "320K", Bitrate: 320},
+		{Codec: "mp3", Quality: "192K", Bitrate: 192},
+		{Codec: "mp3", Quality: "128K", Bitrate: 128},
+		{Codec: "opus", Quality: "160K", Bitrate: 160},
+		{Codec: "opus", Quality: "96K", Bitrate: 96},
+		{Codec: "m4a", Quality: "256K", Bitrate: 256},
+		{Codec: "m4a", Quality: "128K", Bitrate: 128},
+		{Codec: "flac"},
+		{Codec: "wav", Bitrate: 1411},
+	}
+}
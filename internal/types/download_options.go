@@ -30,5 +30,23 @@ func DownloadOptions() []DownloadOption {
 			ConfigField:    "EmbedChapters",
 			RequiresFFmpeg: true,
 		},
+		{
+			Name:           "Add thumbnail",
+			KeyBinding:     tea.KeyCtrlT,
+			ConfigField:    "EmbedThumbnail",
+			RequiresFFmpeg: true,
+		},
+		{
+			Name:           "Mark sponsor segments as chapters",
+			KeyBinding:     tea.KeyCtrlG,
+			ConfigField:    "SponsorBlockMark",
+			RequiresFFmpeg: true,
+		},
+		{
+			Name:           "Remove sponsor segments",
+			KeyBinding:     tea.KeyCtrlX,
+			ConfigField:    "SponsorBlockRemove",
+			RequiresFFmpeg: true,
+		},
 	}
 }
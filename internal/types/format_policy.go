@@ -0,0 +1,35 @@
+package types
+
+type FormatPolicy struct {
+	Name   string
+	Format string
+}
+
+func FormatPolicies(defaultFormat string) []FormatPolicy {
+	return []FormatPolicy{
+		{
+			Name:   "Default (" + defaultFormat + ")",
+			Format: defaultFormat,
+		},
+		{
+			Name:   "Best quality",
+			Format: "bestvideo+bestaudio/best",
+		},
+		{
+			Name:   "Up to 1080p",
+			Format: "bestvideo[height<=1080]+bestaudio/best[height<=1080]",
+		},
+		{
+			Name:   "Up to 720p",
+			Format: "bestvideo[height<=720]+bestaudio/best[height<=720]",
+		},
+		{
+			Name:   "Up to 480p",
+			Format: "bestvideo[height<=480]+bestaudio/best[height<=480]",
+		},
+		{
+			Name:   "Audio only",
+			Format: "bestaudio/best",
+		},
+	}
+}
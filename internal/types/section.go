@@ -0,0 +1,27 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Section is a time range of a video to download, in seconds. An End of 0
+// means the section runs to the end of the video.
+type Section struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end,omitempty"`
+}
+
+// Arg returns the section in yt-dlp's --download-sections syntax.
+func (s Section) Arg() string {
+	end := "inf"
+	if s.End > 0 {
+		end = formatSeconds(s.End)
+	}
+
+	return fmt.Sprintf("*%s-%s", formatSeconds(s.Start), end)
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
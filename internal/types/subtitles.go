@@ -0,0 +1,88 @@
+package types
+
+import "strings"
+
+// SubtitleTrack is a subtitle language offered for a video, either uploaded
+// by the creator or generated automatically by YouTube.
+type SubtitleTrack struct {
+	Lang    string
+	Name    string
+	Auto    bool
+	Formats []string
+}
+
+// Key identifies the track; manual and automatic tracks share language codes.
+func (t SubtitleTrack) Key() string {
+	if t.Auto {
+		return t.Lang + ":auto"
+	}
+
+	return t.Lang
+}
+
+func (t SubtitleTrack) Title() string {
+	if t.Name == "" || t.Name == t.Lang {
+		return t.Lang
+	}
+
+	return t.Name + " (" + t.Lang + ")"
+}
+
+func (t SubtitleTrack) Description() string {
+	kind := "manual"
+	if t.Auto {
+		kind = "auto-generated"
+	}
+
+	if len(t.Formats) == 0 {
+		return kind
+	}
+
+	return kind + " • " + strings.Join(t.Formats, ", ")
+}
+
+func (t SubtitleTrack) FilterValue() string {
+	return t.Name + " " + t.Lang + " " + t.Description()
+}
+
+type SubtitleMode string
+
+const (
+	SubtitleEmbed   SubtitleMode = "embed"
+	SubtitleSidecar SubtitleMode = "sidecar"
+	SubtitleOnly    SubtitleMode = "only"
+)
+
+var subtitleModes = []SubtitleMode{SubtitleEmbed, SubtitleSidecar, SubtitleOnly}
+
+func (m SubtitleMode) Next() SubtitleMode {
+	for i, mode := range subtitleModes {
+		if mode == m {
+			return subtitleModes[(i+1)%len(subtitleModes)]
+		}
+	}
+
+	return SubtitleEmbed
+}
+
+func (m SubtitleMode) Label() string {
+	switch m {
+	case SubtitleSidecar:
+		return "Save next to the video"
+	case SubtitleOnly:
+		return "Subtitles only"
+	}
+
+	return "Embed in the video"
+}
+
+var SubtitleFormats = []string{"srt", "vtt", "ass"}
+
+// SubtitleRequest selects the subtitle tracks to download with a video.
+// Languages are manual tracks and AutoLanguages auto-generated ones.
+type SubtitleRequest struct {
+	Languages     []string     `json:"languages,omitempty"`
+	AutoLanguages []string     `json:"auto_languages,omitempty"`
+	Format        string       `json:"format"`
+	Mode          SubtitleMode `json:"mode"`
+}
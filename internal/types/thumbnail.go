@@ -0,0 +1,37 @@
+package types
+
+import "fmt"
+
+// ThumbnailItem is one of the thumbnail images YouTube offers for a video.
+type ThumbnailItem struct {
+	ID     string
+	URL    string
+	Width  int
+	Height int
+	Ext    string
+}
+
+func (i ThumbnailItem) Title() string {
+	if i.Width == 0 || i.Height == 0 {
+		return "unknown size"
+	}
+
+	return fmt.Sprintf("%dx%d", i.Width, i.Height)
+}
+
+func (i ThumbnailItem) Description() string {
+	return i.Ext + " • id " + i.ID
+}
+
+func (i ThumbnailItem) FilterValue() string {
+	return i.Title() + " " + i.Ext + " " + i.ID
+}
+
+var ThumbnailFormats = []string{"jpg", "png", "webp"}
+
+// ThumbnailRequest asks for a single thumbnail image to be saved in Format
+// instead of downloading the video.
+type ThumbnailRequest struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+}
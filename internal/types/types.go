@@ -1,6 +1,10 @@
 package types
 
-import "github.com/charmbracelet/bubbles/list"
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
 
 const GithubRepoLink = "https://github.com/xdagiz/xytz"
 
@@ -13,6 +17,7 @@ const (
 	StateFormatList  = "format_list"
 	StateDownload    = "download"
 	StateResumeList  = "resume_list"
+	StateLibrary     = "library"
 )
 
 type StartSearchMsg struct {
@@ -25,20 +30,40 @@ type StartFormatMsg struct {
 }
 
 type ProgressMsg struct {
-	Percent     float64
-	Speed       string
-	Eta         string
-	Status      string
-	Destination string
+	JobID           int
+	Percent         float64
+	Speed           string
+	Eta             string
+	Status          string
+	Destination     string
+	DownloadedBytes float64
+	TotalBytes      float64
+	FragmentIndex   int
+	FragmentCount   int
+	VideoID         string
+	Ext             string
+	FormatID        string
+	Phase           DownloadPhase
 }
 
 type VideoItem struct {
-	ID         string
-	VideoTitle string
-	Desc       string
-	Views      float64
-	Duration   float64
-	Channel    string
+	ID            string
+	VideoTitle    string
+	Desc          string
+	Views         float64
+	Duration      float64
+	Channel       string
+	Uploader      string
+	UploadDate    string
+	PlaylistIndex int
+	PlaylistTitle string
+	Chapters      []Chapter
+}
+
+type Chapter struct {
+	Title     string
+	StartTime float64
+	EndTime   float64
 }
 
 func (i VideoItem) Title() string       { return i.VideoTitle }
@@ -57,6 +82,9 @@ type FormatItem struct {
 	Language    string
 	Resolution  string
 	FormatType  string
+	Ext         string
+	Bytes       float64
+	Audio       *AudioPreset
 }
 
 func (i FormatItem) Title() string       { return i.FormatTitle }
@@ -69,38 +97,276 @@ type FormatResultMsg struct {
 	VideoFormats     []list.Item
 	AudioFormats     []list.Item
 	ThumbnailFormats []list.Item
+	SubtitleTracks   []list.Item
 	AllFormats       []list.Item
+	FormatSizes      map[string]float64
 	VideoInfo        VideoItem
 	Err              string
 }
 
+// StartDownloadMsg starts a download once its destination has been checked.
+// Video is set when the video does not come from the lists, as with /get.
 type StartDownloadMsg struct {
 	URL             string
 	FormatID        string
 	DownloadOptions []DownloadOption
+	URLs            []string
+	Videos          []VideoItem
+	Playlist        bool
+	StreamSizes     map[string]float64
+	Skipped         int
+	AudioFormat     string
+	AudioQuality    string
+	Sections        []Section
+	ForceKeyframes  bool
+	Subtitles       *SubtitleRequest
+	Thumbnail       *ThumbnailRequest
+	EstimatedBytes  float64
+	OutputDir       string
+	OutputTemplate  string
+	Overwrite       bool
+	Checked         bool
+	Video           *VideoItem
+}
+
+// PreflightMsg reports problems found at the destination of a download
+// before it starts. Start is the download to run once the user decides.
+// DuplicateURLs lists the videos of a batch that are already there; KeepBoth
+// is only set for single videos.
+type PreflightMsg struct {
+	Start          StartDownloadMsg
+	EstimatedBytes float64
+	FreeBytes      float64
+	Duplicates     []string
+	DuplicateURLs  []string
+	OutputDir      string
+	KeepBoth       string
+	Err            string
+}
+
+// LowSpace reports whether the estimated size does not fit in the free space.
+func (m PreflightMsg) LowSpace() bool {
+	return m.EstimatedBytes > 0 && m.FreeBytes > 0 && m.EstimatedBytes > m.FreeBytes
+}
+
+func (m PreflightMsg) OK() bool {
+	return !m.LowSpace() && len(m.Duplicates) == 0 && m.Err == ""
+}
+
+// QuickDownloadMsg downloads without going through the format list, using
+// the active profile's format or the configured default. URLs come from the
+// video list, URL from /get.
+type QuickDownloadMsg struct {
+	URL     string
+	URLs    []string
+	Videos  []VideoItem
+	Skipped int
 }
 
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "downloading"
+	JobPaused    JobStatus = "paused"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+type DownloadPhase string
+
+const (
+	PhaseDownloading         DownloadPhase = "downloading"
+	PhaseMerging             DownloadPhase = "merging"
+	PhaseExtractingAudio     DownloadPhase = "extracting_audio"
+	PhaseEmbeddingSubtitles  DownloadPhase = "embedding_subtitles"
+	PhaseConvertingSubtitles DownloadPhase = "converting_subtitles"
+	PhaseEmbeddingThumbnail  DownloadPhase = "embedding_thumbnail"
+	PhaseConvertingThumbnail DownloadPhase = "converting_thumbnail"
+	PhaseAddingMetadata      DownloadPhase = "adding_metadata"
+	PhaseFixup               DownloadPhase = "fixup"
+	PhaseSponsorBlock        DownloadPhase = "sponsorblock"
+)
+
+// IsPostProcessing reports whether the phase runs after the download itself.
+func (p DownloadPhase) IsPostProcessing() bool {
+	return p != "" && p != PhaseDownloading
+}
+
+func (p DownloadPhase) Label() string {
+	switch p {
+	case PhaseDownloading:
+		return "Downloading"
+	case PhaseMerging:
+		return "Merging formats"
+	case PhaseExtractingAudio:
+		return "Extracting audio"
+	case PhaseEmbeddingSubtitles:
+		return "Embedding subtitles"
+	case PhaseConvertingSubtitles:
+		return "Converting subtitles"
+	case PhaseEmbeddingThumbnail:
+		return "Embedding thumbnail"
+	case PhaseConvertingThumbnail:
+		return "Converting thumbnail"
+	case PhaseAddingMetadata:
+		return "Adding metadata"
+	case PhaseFixup:
+		return "Fixing up container"
+	case PhaseSponsorBlock:
+		return "Processing SponsorBlock segments"
+	}
+
+	return string(p)
+}
+
+// DownloadRequest describes a single yt-dlp run. With SkipArchived set, videos
+// already in the download archive are skipped; otherwise they are only
+// recorded in it once downloaded. A non-empty AudioFormat extracts the audio
+// into that format after downloading, and Sections limits the download to
+// the given time ranges. With Thumbnail set only that image is saved.
+type DownloadRequest struct {
+	JobID          int
+	URL            string
+	FormatID       string
+	Title          string
+	VideoID        string
+	Channel        string
+	Duration       float64
+	Options        []DownloadOption
+	OutputDir      string
+	OutputTemplate string
+	Playlist       bool
+	SkipArchived   bool
+	AudioFormat    string
+	AudioQuality   string
+	Sections       []Section
+	ForceKeyframes bool
+	Subtitles      *SubtitleRequest
+	Thumbnail      *ThumbnailRequest
+	Profile        string
+	ExtraArgs      []string
+	Overwrite      bool
+}
+
+type PlaylistItemStatus string
+
+const (
+	ItemPending     PlaylistItemStatus = "pending"
+	ItemDownloading PlaylistItemStatus = "downloading"
+	ItemDone        PlaylistItemStatus = "done"
+	ItemFailed      PlaylistItemStatus = "failed"
+	ItemSkipped     PlaylistItemStatus = "skipped"
+)
+
+type PlaylistItemMsg struct {
+	JobID   int
+	Index   int
+	Total   int
+	VideoID string
+	Status  PlaylistItemStatus
+	Err     string
+}
+
+type DownloadStartedMsg struct {
+	JobID     int
+	RateLimit string
+}
+
+type DownloadRetryMsg struct {
+	JobID       int
+	Attempt     int
+	MaxAttempts int
+	Delay       time.Duration
+	Err         string
+}
+
+// FetchRetryMsg reports that a search or format fetch is waiting to retry.
+type FetchRetryMsg struct {
+	Attempt     int
+	MaxAttempts int
+	Delay       time.Duration
+	Err         string
+}
+
+type RateLimitMsg struct {
+	JobID int
+	Limit string
+}
+
+type RateScheduleTickMsg struct{}
+
 type DownloadResultMsg struct {
-	Output string
-	Err    string
+	JobID     int
+	Err       string
+	Cancelled bool
+}
+
+// DownloadFinishedMsg reports a completed download. SponsorBlockRemoved is
+// the number of seconds cut from the video by SponsorBlock.
+type DownloadFinishedMsg struct {
+	JobID                int
+	URL                  string
+	FilePaths            []string
+	SponsorBlockSegments int
+	SponsorBlockRemoved  float64
+}
+
+func (m DownloadFinishedMsg) FilePath() string {
+	if len(m.FilePaths) == 0 {
+		return ""
+	}
+
+	return m.FilePaths[len(m.FilePaths)-1]
 }
 
 type DownloadCompleteMsg struct{}
 
-type PauseDownloadMsg struct{}
+type HookResultMsg struct {
+	JobID int
+	Event string
+	Err   string
+}
+
+type ClearNoticeMsg struct{}
+
+// ProfileSelectedMsg switches the active download profile. An empty Name
+// clears it. Download starts a download of the current video with the
+// profile's format.
+type ProfileSelectedMsg struct {
+	Name     string
+	Download bool
+}
+
+type AuthResultMsg struct {
+	URL   string
+	Title string
+	Err   string
+}
+
+type PauseDownloadMsg struct {
+	JobID int
+}
+
+type ResumeDownloadMsg struct {
+	JobID int
+}
+
+type CancelDownloadMsg struct {
+	JobID int
+}
 
-type ResumeDownloadMsg struct{}
+type ShowDownloadsMsg struct{}
 
-type CancelDownloadMsg struct{}
+type ShowLibraryMsg struct{}
 
 type CancelSearchMsg struct{}
 
 type CancelFormatsMsg struct{}
 
 type StartResumeDownloadMsg struct {
-	URL      string
-	FormatID string
-	Title    string
+	Request DownloadRequest
 }
 
 type StartChannelURLMsg struct {
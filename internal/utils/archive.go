@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// archiveExtractor is the extractor key yt-dlp writes for YouTube videos in
+// --download-archive files.
+const archiveExtractor = "youtube"
+
+var archiveMutex sync.Mutex
+
+// LoadArchive returns the YouTube video ids recorded in a yt-dlp download
+// archive. A missing file is treated as an empty archive.
+func LoadArchive(path string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	if path == "" {
+		return ids, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ids, nil
+		}
+
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		extractor, id, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if ok && strings.EqualFold(extractor, archiveExtractor) {
+			ids[id] = true
+		}
+	}
+
+	return ids, scanner.Err()
+}
+
+// AddToArchive records a video in the archive the same way yt-dlp does.
+func AddToArchive(path, videoID string) error {
+	if path == "" || videoID == "" {
+		return nil
+	}
+
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	ids, err := LoadArchive(path)
+	if err != nil {
+		return err
+	}
+
+	if ids[videoID] {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s %s\n", archiveExtractor, videoID)
+	return err
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/config"
+	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/ytdlperr"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CookieArgs returns the yt-dlp options that authenticate requests. Cookies
+// from a browser take precedence over a cookies file.
+func CookieArgs(cfg *config.Config) []string {
+	if browser := strings.TrimSpace(cfg.CookiesFromBrowser); browser != "" {
+		return []string{"--cookies-from-browser", browser}
+	}
+
+	if path := cfg.GetCookiesPath(); path != "" {
+		return []string{"--cookies", path}
+	}
+
+	return nil
+}
+
+// AuthDescription describes the authentication method in use.
+func AuthDescription(cfg *config.Config) string {
+	if browser := strings.TrimSpace(cfg.CookiesFromBrowser); browser != "" {
+		return "Cookies from browser: " + browser
+	}
+
+	path := cfg.GetCookiesPath()
+	if path == "" {
+		return "None, set cookies or cookies_from_browser in your config"
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return "Cookies file: " + path + " (not found)"
+	}
+
+	return "Cookies file: " + path
+}
+
+// TestAuth fetches the title of url with the configured cookies, to check
+// that they give access to it.
+func TestAuth(url string) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		cfg, err := config.Load()
+		if err != nil {
+			cfg = config.GetDefault()
+		}
+
+		args := append(BaseArgs(cfg), "--print", "title", "--playlist-items", "1", url)
+		cmd := exec.Command(ytdlpBinary(cfg.YTDLPPath), args...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		out, err := cmd.Output()
+		title, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		if err == nil && title != "" {
+			return types.AuthResultMsg{URL: url, Title: title}
+		}
+
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if classified := ytdlperr.Classify(lines); classified != nil {
+			return types.AuthResultMsg{URL: url, Err: classified.Message}
+		}
+
+		if err != nil {
+			return types.AuthResultMsg{URL: url, Err: err.Error()}
+		}
+
+		return types.AuthResultMsg{URL: url, Err: "yt-dlp returned nothing for this URL"}
+	})
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xdagiz/xytz/internal/types"
+)
+
+const CompletedFileName = "downloads.json"
+
+var completedMutex sync.Mutex
+
+type CompletedDownload struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Channel   string    `json:"channel,omitempty"`
+	FormatID  string    `json:"format_id"`
+	FilePath  string    `json:"file_path"`
+	Size      int64     `json:"size,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewCompletedDownload records a file written by req. Playlist downloads
+// produce one entry per file, titled after the file.
+func NewCompletedDownload(req types.DownloadRequest, path string) CompletedDownload {
+	title := req.Title
+	if req.Playlist {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+
+	return CompletedDownload{
+		URL:       req.URL,
+		Title:     title,
+		Channel:   req.Channel,
+		FormatID:  req.FormatID,
+		FilePath:  path,
+		Size:      size,
+		Timestamp: time.Now(),
+	}
+}
+
+// Same reports whether d and other are the same index entry.
+func (d CompletedDownload) Same(other CompletedDownload) bool {
+	return d.FilePath == other.FilePath && d.Timestamp.Equal(other.Timestamp)
+}
+
+func GetCompletedFilePath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("Warning: Could not get home directory: %v", err)
+		return CompletedFileName
+	}
+
+	localDir := filepath.Join(homeDir, ".local", "share", "xytz")
+
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		log.Printf("Warning: Could not create directory %s: %v", localDir, err)
+		return filepath.Join(homeDir, CompletedFileName)
+	}
+
+	return filepath.Join(localDir, CompletedFileName)
+}
+
+func LoadCompleted() ([]CompletedDownload, error) {
+	path := GetCompletedFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CompletedDownload{}, nil
+		}
+
+		return nil, err
+	}
+
+	var downloads []CompletedDownload
+	if err := json.Unmarshal(data, &downloads); err != nil {
+		return nil, err
+	}
+
+	return downloads, nil
+}
+
+func SaveCompleted(downloads []CompletedDownload) error {
+	path := GetCompletedFilePath()
+	data, err := json.MarshalIndent(downloads, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func AddCompleted(download CompletedDownload) error {
+	completedMutex.Lock()
+	defer completedMutex.Unlock()
+
+	downloads, err := LoadCompleted()
+	if err != nil {
+		return err
+	}
+
+	downloads = append(downloads, download)
+	return SaveCompleted(downloads)
+}
+
+// RemoveCompleted drops download from the index. The file is left alone.
+func RemoveCompleted(download CompletedDownload) error {
+	completedMutex.Lock()
+	defer completedMutex.Unlock()
+
+	downloads, err := LoadCompleted()
+	if err != nil {
+		return err
+	}
+
+	downloads = slices.DeleteFunc(downloads, download.Same)
+	return SaveCompleted(downloads)
+}
+
+// DeleteCompleted deletes the downloaded file and its index entry. A file
+// that is already gone only loses its entry.
+func DeleteCompleted(download CompletedDownload) error {
+	if err := os.Remove(download.FilePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return RemoveCompleted(download)
+}
@@ -5,154 +5,514 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"os/exec"
-	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xdagiz/xytz/internal/config"
 	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/ytdlperr"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+type downloadJob struct {
+	program      *tea.Program
+	req          types.DownloadRequest
+	cfg          *config.Config
+	cmd          *exec.Cmd
+	ctx          context.Context
+	cancel       context.CancelFunc
+	paused       bool
+	cancelled    bool
+	rateLimit    string
+	runLimit     string
+	rateOverride bool
+	restarting   bool
+	postProcess  bool
+	attempts     int
+}
+
+// DownloadManager runs up to maxConcurrent yt-dlp processes at once and
+// keeps the rest waiting in a FIFO queue.
+type DownloadManager struct {
+	mu            sync.Mutex
+	maxConcurrent int
+	active        map[int]*downloadJob
+	queue         []*downloadJob
+}
+
 var (
-	currentCmd    *exec.Cmd
-	currentCtx    context.Context
-	currentCancel context.CancelFunc
-	downloadMutex sync.Mutex
-	isPaused      bool
+	downloadManager = NewDownloadManager(config.DefaultMaxConcurrentDownloads)
+	lastDownloadID  atomic.Int64
 )
 
-func StartDownload(program *tea.Program, url, formatID string, title string, options []types.DownloadOption) tea.Cmd {
-	return tea.Cmd(func() tea.Msg {
-		unfinished := UnfinishedDownload{
-			URL:       url,
-			FormatID:  formatID,
-			Title:     title,
-			Timestamp: time.Now(),
+func NewDownloadManager(maxConcurrent int) *DownloadManager {
+	return &DownloadManager{
+		maxConcurrent: maxConcurrent,
+		active:        make(map[int]*downloadJob),
+	}
+}
+
+// NextDownloadID returns a new unique job id. The id is allocated before the
+// download is queued so the UI can track the job from the very first message.
+func NextDownloadID() int {
+	return int(lastDownloadID.Add(1))
+}
+
+func (dm *DownloadManager) SetMaxConcurrent(n int) {
+	if n <= 0 {
+		return
+	}
+
+	dm.mu.Lock()
+	dm.maxConcurrent = n
+	dm.mu.Unlock()
+}
+
+func (dm *DownloadManager) Enqueue(program *tea.Program, req types.DownloadRequest, cfg *config.Config) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	job := &downloadJob{program: program, req: req, cfg: cfg}
+	if len(dm.active) < dm.maxConcurrent {
+		dm.startLocked(program, job)
+		return
+	}
+
+	dm.queue = append(dm.queue, job)
+}
+
+func (dm *DownloadManager) startLocked(program *tea.Program, job *downloadJob) {
+	job.ctx, job.cancel = context.WithCancel(context.Background())
+	dm.active[job.req.JobID] = job
+
+	if !job.rateOverride {
+		job.rateLimit = job.cfg.RateLimitAt(time.Now())
+	}
+
+	go func() {
+		for doDownload(program, dm, job) {
 		}
 
-		if err := AddUnfinished(unfinished); err != nil {
-			log.Printf("Failed to add to unfinished list: %v", err)
+		dm.mu.Lock()
+		delete(dm.active, job.req.JobID)
+		for len(dm.queue) > 0 && len(dm.active) < dm.maxConcurrent {
+			next := dm.queue[0]
+			dm.queue = dm.queue[1:]
+			dm.startLocked(program, next)
 		}
+		dm.mu.Unlock()
+	}()
+}
 
+// Cancel stops a running job or drops a queued one. Queued jobs never reach
+// doDownload, so their cancelled result is reported here.
+func (dm *DownloadManager) Cancel(id int) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	for i, job := range dm.queue {
+		if job.req.JobID == id {
+			dm.queue = append(dm.queue[:i], dm.queue[i+1:]...)
+			go cancelledDownload(job.program, job)
+			return
+		}
+	}
+
+	job, ok := dm.active[id]
+	if !ok {
+		return
+	}
+
+	job.cancelled = true
+	if job.cancel != nil {
+		job.cancel()
+	}
+
+	if job.cmd != nil && job.cmd.Process != nil {
+		if err := job.cmd.Process.Kill(); err != nil {
+			log.Printf("Failed to kill download process: %v", err)
+		}
+	}
+}
+
+// restartLocked kills the running yt-dlp process so that it is started again
+// with a new rate limit. yt-dlp picks up the partial file where it left off.
+// Killing yt-dlp would leave ffmpeg writing the file during post-processing,
+// so the restart then waits until the next playlist item starts.
+func (dm *DownloadManager) restartLocked(job *downloadJob, limit string) {
+	job.rateLimit = limit
+	if job.cmd == nil || job.cmd.Process == nil || job.restarting || job.postProcess || job.runLimit == limit {
+		return
+	}
+
+	job.restarting = true
+	if err := job.cmd.Process.Kill(); err != nil {
+		log.Printf("Failed to restart download %d: %v", job.req.JobID, err)
+	}
+}
+
+func (dm *DownloadManager) SetRateLimit(id int, limit string) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	for _, job := range dm.queue {
+		if job.req.JobID == id {
+			job.rateLimit = limit
+			job.rateOverride = true
+			return
+		}
+	}
+
+	if job, ok := dm.active[id]; ok {
+		job.rateOverride = true
+		dm.restartLocked(job, limit)
+	}
+}
+
+// ApplyRateSchedule restarts running downloads whose rate limit no longer
+// matches the schedule. Jobs with a limit set by hand are left alone.
+func (dm *DownloadManager) ApplyRateSchedule(cfg *config.Config, now time.Time) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	limit := cfg.RateLimitAt(now)
+	for _, job := range dm.active {
+		if !job.rateOverride && job.rateLimit != limit {
+			dm.restartLocked(job, limit)
+		}
+	}
+}
+
+func (dm *DownloadManager) setPaused(id int, paused bool, signal func(*exec.Cmd) error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	job, ok := dm.active[id]
+	if !ok || job.cmd == nil || job.cmd.Process == nil || job.paused == paused {
+		return
+	}
+
+	job.paused = paused
+	if err := signal(job.cmd); err != nil {
+		log.Printf("Failed to change pause state of download %d: %v", id, err)
+	}
+}
+
+func StartDownload(program *tea.Program, req types.DownloadRequest) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
 		cfg, err := config.Load()
 		if err != nil {
 			cfg = config.GetDefault()
 		}
-		downloadPath := cfg.GetDownloadPath()
-		go doDownload(program, url, formatID, downloadPath, cfg.YTDLPPath, options)
+
+		req = ResolveRequest(req, cfg)
+
+		if err := AddUnfinished(NewUnfinishedDownload(req)); err != nil {
+			log.Printf("Failed to add to unfinished list: %v", err)
+		}
+
+		downloadManager.SetMaxConcurrent(cfg.MaxConcurrentDownloads)
+		downloadManager.Enqueue(program, req, cfg)
 
 		return nil
 	})
 }
 
-func CancelDownload() tea.Cmd {
+// ResolveRequest fills in the settings req leaves to the active profile and
+// the config, such as the output directory and template.
+func ResolveRequest(req types.DownloadRequest, cfg *config.Config) types.DownloadRequest {
+	if req.Profile != "" {
+		if profile, ok := cfg.FindProfile(req.Profile); ok {
+			applyProfile(&req, profile, cfg)
+		} else {
+			log.Printf("Profile %q not found, using the default settings", req.Profile)
+		}
+	}
+
+	if req.OutputDir == "" {
+		req.OutputDir = cfg.GetDownloadPath()
+	}
+	if req.OutputTemplate == "" {
+		req.OutputTemplate = cfg.OutputTemplate
+	}
+
+	return req
+}
+
+var rateLimitRegex = regexp.MustCompile(`^\d+(?:\.\d+)?[KMGTkmgt]?$`)
+
+// ValidRateLimit reports whether limit is a --limit-rate value yt-dlp
+// accepts, e.g. 500K or 2M. An empty limit means no limit.
+func ValidRateLimit(limit string) bool {
+	return limit == "" || rateLimitRegex.MatchString(limit)
+}
+
+func SetRateLimit(jobID int, limit string) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
-		downloadMutex.Lock()
-		defer downloadMutex.Unlock()
+		downloadManager.SetRateLimit(jobID, limit)
+		return types.RateLimitMsg{JobID: jobID, Limit: limit}
+	})
+}
 
-		if currentCancel != nil {
-			currentCancel()
-		}
+func RateScheduleTick() tea.Cmd {
+	return tea.Tick(time.Minute, func(time.Time) tea.Msg {
+		return types.RateScheduleTickMsg{}
+	})
+}
 
-		if currentCmd != nil && currentCmd.Process != nil {
-			if err := currentCmd.Process.Kill(); err != nil {
-				log.Printf("Failed to kill download process: %v", err)
-			}
+func ApplyRateSchedule() tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		cfg, err := config.Load()
+		if err != nil {
+			cfg = config.GetDefault()
 		}
 
-		return types.CancelDownloadMsg{}
+		downloadManager.ApplyRateSchedule(cfg, time.Now())
+		return nil
 	})
 }
 
-func doDownload(program *tea.Program, url, formatID, outputPath, ytDlpPath string, options []types.DownloadOption) {
-	downloadMutex.Lock()
-	currentCtx, currentCancel = context.WithCancel(context.Background())
-	downloadMutex.Unlock()
+func CancelDownload(jobID int) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		downloadManager.Cancel(jobID)
+		return types.CancelDownloadMsg{JobID: jobID}
+	})
+}
 
-	if ytDlpPath == "" {
-		ytDlpPath = "yt-dlp"
-	}
+// doDownload runs yt-dlp for job and reports the outcome to the UI. It returns
+// true when the process was stopped to be restarted with a new rate limit.
+func doDownload(program *tea.Program, dm *DownloadManager, job *downloadJob) bool {
+	req := job.req
+	ytDlpPath := ytdlpBinary(job.cfg.YTDLPPath)
 
-	if url == "" {
+	if req.URL == "" {
 		log.Printf("download error: empty URL provided")
-		program.Send(types.DownloadResultMsg{Err: "Download error: empty URL provided"})
-		return
+		failDownload(program, job, "Download error: empty URL provided")
+		return false
 	}
 
-	isPlaylist := strings.Contains(url, "/playlist?list=") || strings.Contains(url, "&list=")
+	if req.Thumbnail != nil {
+		downloadThumbnail(program, job)
+		return false
+	}
+
+	isPlaylist := req.Playlist || IsPlaylistURL(req.URL)
+
+	outputTemplate := req.OutputTemplate
+	if len(req.Sections) > 0 {
+		if !SupportsDownloadSections(ytDlpPath) {
+			failDownload(program, job, "Section downloads need yt-dlp "+downloadSectionsVersion+" or newer")
+			return false
+		}
+
+		outputTemplate = SectionTemplate(outputTemplate)
+	}
 
 	args := []string{
 		"-f",
-		formatID,
+		req.FormatID,
 		"--newline",
 		"-R",
 		"infinite",
 		"-o",
-		filepath.Join(outputPath, "%(title)s.%(ext)s"),
-		url,
+		OutputPath(req.OutputDir, outputTemplate),
+		req.URL,
 	}
 
 	if !isPlaylist {
 		args = append([]string{"--no-playlist"}, args...)
 	}
 
-	for _, opt := range options {
+	dm.mu.Lock()
+	rateLimit := job.rateLimit
+	dm.mu.Unlock()
+
+	if rateLimit != "" {
+		args = append([]string{"--limit-rate", rateLimit}, args...)
+	}
+
+	args = append(BaseArgs(job.cfg), args...)
+
+	// yt-dlp keeps the archive itself whenever a run can cover several videos.
+	subtitlesOnly := req.Subtitles != nil && req.Subtitles.Mode == types.SubtitleOnly
+	archivePath := job.cfg.GetArchivePath()
+	if archivePath != "" && (req.SkipArchived || (isPlaylist && !subtitlesOnly)) {
+		args = append([]string{"--download-archive", archivePath}, args...)
+	}
+
+	if SupportsProgressTemplate(ytDlpPath) {
+		args = append([]string{"--progress-template", ProgressTemplate}, args...)
+	}
+
+	var pathsFile *os.File
+	if SupportsPrintToFile(ytDlpPath) {
+		var err error
+		pathsFile, err = os.CreateTemp("", "xytz-paths-*.txt")
+		if err != nil {
+			log.Printf("Failed to create output path file: %v", err)
+		} else {
+			pathsFile.Close()
+			defer os.Remove(pathsFile.Name())
+			args = append([]string{"--print-to-file", "after_move:filepath", pathsFile.Name()}, args...)
+		}
+	}
+
+	for _, section := range req.Sections {
+		args = append(args, "--download-sections", section.Arg())
+	}
+	if len(req.Sections) > 0 && req.ForceKeyframes {
+		args = append(args, "--force-keyframes-at-cuts")
+	}
+
+	if req.Overwrite {
+		args = append(args, "--force-overwrites")
+	}
+
+	if req.AudioFormat != "" {
+		args = append(args, "-x", "--audio-format", req.AudioFormat)
+		if req.AudioQuality != "" {
+			args = append(args, "--audio-quality", req.AudioQuality)
+		}
+	}
+
+	if req.Subtitles != nil {
+		args = append(args, subtitleArgs(req.Subtitles)...)
+	}
+
+	categories := SponsorBlockCategories(job.cfg)
+	var removedCategories []string
+	var segmentsFile *os.File
+
+	for _, opt := range req.Options {
 		if opt.Enabled {
 			switch opt.ConfigField {
 			case "EmbedSubtitles":
-				args = append(args, "--embed-subs")
+				// A subtitle selection from the Subtitles tab decides how
+				// subtitles are handled instead.
+				if req.Subtitles == nil {
+					args = append(args, "--embed-subs")
+				}
 			case "EmbedMetadata":
 				args = append(args, "--embed-metadata")
 			case "EmbedChapters":
 				args = append(args, "--embed-chapters")
+			case "EmbedThumbnail":
+				// wav has no place to store cover art, and yt-dlp fails the
+				// download rather than skipping the thumbnail.
+				if req.AudioFormat != "wav" {
+					args = append(args, "--embed-thumbnail")
+				}
+			case "SponsorBlockMark":
+				if len(categories) > 0 {
+					args = append(args, "--sponsorblock-mark", strings.Join(categories, ","))
+				}
+			case "SponsorBlockRemove":
+				// Highlights and full-video labels mark a point or the whole
+				// video, so yt-dlp does not accept them for removal.
+				removedCategories = slices.DeleteFunc(slices.Clone(categories), func(c string) bool {
+					return c == "poi_highlight" || c == "chapter"
+				})
+				if len(removedCategories) == 0 {
+					break
+				}
+
+				args = append(args, "--sponsorblock-remove", strings.Join(removedCategories, ","))
+				if SupportsPrintToFile(ytDlpPath) {
+					var err error
+					segmentsFile, err = os.CreateTemp("", "xytz-sponsorblock-*.txt")
+					if err != nil {
+						log.Printf("Failed to create SponsorBlock segment file: %v", err)
+					} else {
+						segmentsFile.Close()
+						defer os.Remove(segmentsFile.Name())
+						args = append(args, "--print-to-file", sponsorBlockTemplate, segmentsFile.Name())
+					}
+				}
 			}
 		}
 	}
 
-	cmd := exec.CommandContext(currentCtx, ytDlpPath, args...)
+	args = append(args, req.ExtraArgs...)
 
-	downloadMutex.Lock()
-	currentCmd = cmd
-	isPaused = false
-	downloadMutex.Unlock()
+	cmd := exec.CommandContext(job.ctx, ytDlpPath, args...)
+
+	// Only the first run may replace the existing file. Restarts and retries
+	// continue from the partial file instead.
+	dm.mu.Lock()
+	job.cmd = cmd
+	job.paused = false
+	job.req.Overwrite = false
+	job.runLimit = rateLimit
+	job.postProcess = false
+	dm.mu.Unlock()
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		log.Printf("pipe error: %v", err)
 		errMsg := fmt.Sprintf("pipe error: %v", err)
-		program.Send(types.DownloadResultMsg{Err: errMsg})
-		return
+		failDownload(program, job, errMsg)
+		return false
 	}
 
 	stderr, err2 := cmd.StderrPipe()
 	if err2 != nil {
 		log.Printf("stderr pipe error: %v", err2)
 		errMsg := fmt.Sprintf("stderr pipe error: %v", err2)
-		program.Send(types.DownloadResultMsg{Err: errMsg})
-		return
+		failDownload(program, job, errMsg)
+		return false
 	}
 
 	if err := cmd.Start(); err != nil {
 		log.Printf("start error: %v", err)
 		errMsg := fmt.Sprintf("start error: %v", err)
-		program.Send(types.DownloadResultMsg{Err: errMsg})
-		return
+		failDownload(program, job, errMsg)
+		return false
 	}
 
-	parser := NewProgressParser()
+	program.Send(types.DownloadStartedMsg{JobID: req.JobID, RateLimit: rateLimit})
+
+	// Keep the unfinished record pointing at the file being written. The
+	// parser sends messages one at a time, so these need no locking.
+	var lastDestination string
+	var lastTotal float64
+	send := func(msg tea.Msg) {
+		if progress, ok := msg.(types.ProgressMsg); ok {
+			dm.mu.Lock()
+			job.postProcess = progress.Phase.IsPostProcessing()
+			if !job.postProcess && job.rateLimit != job.runLimit {
+				dm.restartLocked(job, job.rateLimit)
+			}
+			dm.mu.Unlock()
+		}
+
+		if progress, ok := msg.(types.ProgressMsg); ok && progress.Phase == types.PhaseDownloading && progress.Destination != "" {
+			if progress.Destination != lastDestination || (lastTotal == 0 && progress.TotalBytes > 0) {
+				lastDestination = progress.Destination
+				lastTotal = progress.TotalBytes
+				if err := UpdateUnfinishedProgress(req.URL, progress.Destination, progress.TotalBytes); err != nil {
+					log.Printf("Failed to update unfinished download: %v", err)
+				}
+			}
+		}
+
+		program.Send(msg)
+	}
+
+	parser := NewProgressParser(req.JobID)
 	var wg sync.WaitGroup
 	readPipe := func(pipe io.Reader) {
-		wg.Add(1)
 		defer wg.Done()
-		parser.ReadPipe(pipe, func(percent float64, speed, eta, status, destination string) {
-			program.Send(types.ProgressMsg{Percent: percent, Speed: speed, Eta: eta, Status: status, Destination: destination})
-		})
+		parser.ReadPipe(pipe, send)
 	}
 
+	wg.Add(2)
 	go readPipe(stdout)
 	go readPipe(stderr)
 	wg.Wait()
@@ -170,25 +530,115 @@ func doDownload(program *tea.Program, url, formatID, outputPath, ytDlpPath strin
 		}
 	}
 
-	downloadMutex.Lock()
-	currentCmd = nil
-	currentCancel = nil
-	isPaused = false
-	downloadMutex.Unlock()
+	dm.mu.Lock()
+	job.cmd = nil
+	job.paused = false
+	restarting := job.restarting
+	job.restarting = false
+	cancelled := job.cancelled
+	dm.mu.Unlock()
+
+	if cancelled {
+		cancelledDownload(program, job)
+		return false
+	}
 
-	if currentCtx.Err() == context.Canceled {
-		program.Send(types.DownloadResultMsg{Err: "Download cancelled"})
-		return
+	if restarting {
+		return true
 	}
 
 	if err != nil {
 		errMsg := fmt.Sprintf("Download error: %v", err)
-		program.Send(types.DownloadResultMsg{Err: errMsg})
-	} else {
-		if err := RemoveUnfinished(url); err != nil {
-			log.Printf("Failed to remove from unfinished list: %v", err)
+		if classified := ytdlperr.Classify(parser.Errors()); classified != nil {
+			errMsg = "Download error: " + classified.Message
+			job.attempts++
+			if delay, ok := classified.Policy.Next(job.attempts); ok {
+				log.Printf("Download %d failed (%s), retrying in %s", req.JobID, classified.Kind, delay)
+				program.Send(types.DownloadRetryMsg{
+					JobID:       req.JobID,
+					Attempt:     job.attempts + 1,
+					MaxAttempts: classified.Policy.MaxAttempts,
+					Delay:       delay,
+					Err:         classified.Message,
+				})
+
+				select {
+				case <-time.After(delay):
+					return true
+				case <-job.ctx.Done():
+					cancelledDownload(program, job)
+					return false
+				}
+			}
+		}
+
+		failDownload(program, job, errMsg)
+		return false
+	}
+
+	if err := RemoveUnfinished(req.URL); err != nil {
+		log.Printf("Failed to remove from unfinished list: %v", err)
+	}
+
+	var filePaths []string
+	if pathsFile != nil {
+		filePaths = readOutputPaths(pathsFile.Name())
+	}
+	if len(filePaths) == 0 && parser.currentDestination != "" {
+		filePaths = []string{parser.currentDestination}
+	}
+
+	// Clips and subtitles-only downloads do not count as having the video.
+	if archivePath != "" && !req.SkipArchived && !isPlaylist && !subtitlesOnly && len(req.Sections) == 0 {
+		videoID := req.VideoID
+		if videoID == "" {
+			videoID = ExtractVideoID(req.URL)
+		}
+		if err := AddToArchive(archivePath, videoID); err != nil {
+			log.Printf("Failed to add to download archive: %v", err)
+		}
+	}
+
+	for _, path := range filePaths {
+		if err := AddCompleted(NewCompletedDownload(req, path)); err != nil {
+			log.Printf("Failed to add to completed list: %v", err)
 		}
+	}
 
-		program.Send(types.DownloadResultMsg{Output: "Download complete"})
+	finished := types.DownloadFinishedMsg{JobID: req.JobID, URL: req.URL, FilePaths: filePaths}
+	if segmentsFile != nil {
+		finished.SponsorBlockSegments, finished.SponsorBlockRemoved = readSponsorBlockSummary(segmentsFile.Name(), removedCategories)
 	}
+
+	program.Send(finished)
+	RunHook(program, job.cfg, HookComplete, req, filePaths, "")
+
+	return false
+}
+
+func failDownload(program *tea.Program, job *downloadJob, errMsg string) {
+	program.Send(types.DownloadResultMsg{JobID: job.req.JobID, Err: errMsg})
+	RunHook(program, job.cfg, HookError, job.req, nil, errMsg)
+}
+
+func cancelledDownload(program *tea.Program, job *downloadJob) {
+	program.Send(types.DownloadResultMsg{JobID: job.req.JobID, Err: "Download cancelled", Cancelled: true})
+	RunHook(program, job.cfg, HookCancel, job.req, nil, "Download cancelled")
+}
+
+func readOutputPaths(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Failed to read output paths: %v", err)
+		return nil
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+
+	return paths
 }
@@ -3,7 +3,7 @@
 package utils
 
 import (
-	"log"
+	"os/exec"
 	"syscall"
 
 	"github.com/xdagiz/xytz/internal/types"
@@ -11,34 +11,22 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func PauseDownload() tea.Cmd {
+func PauseDownload(jobID int) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
-		downloadMutex.Lock()
-		defer downloadMutex.Unlock()
+		downloadManager.setPaused(jobID, true, func(cmd *exec.Cmd) error {
+			return cmd.Process.Signal(syscall.SIGSTOP)
+		})
 
-		if currentCmd != nil && currentCmd.Process != nil && !isPaused {
-			isPaused = true
-			if err := currentCmd.Process.Signal(syscall.SIGSTOP); err != nil {
-				log.Printf("Failed to pause download: %v", err)
-			}
-		}
-
-		return types.PauseDownloadMsg{}
+		return types.PauseDownloadMsg{JobID: jobID}
 	})
 }
 
-func ResumeDownload() tea.Cmd {
+func ResumeDownload(jobID int) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
-		downloadMutex.Lock()
-		defer downloadMutex.Unlock()
-
-		if currentCmd != nil && currentCmd.Process != nil && isPaused {
-			isPaused = false
-			if err := currentCmd.Process.Signal(syscall.SIGCONT); err != nil {
-				log.Printf("Failed to resume download: %v", err)
-			}
-		}
+		downloadManager.setPaused(jobID, false, func(cmd *exec.Cmd) error {
+			return cmd.Process.Signal(syscall.SIGCONT)
+		})
 
-		return types.ResumeDownloadMsg{}
+		return types.ResumeDownloadMsg{JobID: jobID}
 	})
 }
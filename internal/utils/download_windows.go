@@ -8,28 +8,16 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func PauseDownload() tea.Cmd {
+func PauseDownload(jobID int) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
-		downloadMutex.Lock()
-		defer downloadMutex.Unlock()
-
-		if currentCmd != nil && currentCmd.Process != nil && !isPaused {
-			// Pause not supported on Windows
-		}
-
-		return types.PauseDownloadMsg{}
+		// Pause not supported on Windows
+		return types.PauseDownloadMsg{JobID: jobID}
 	})
 }
 
-func ResumeDownload() tea.Cmd {
+func ResumeDownload(jobID int) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
-		downloadMutex.Lock()
-		defer downloadMutex.Unlock()
-
-		if currentCmd != nil && currentCmd.Process != nil && isPaused {
-			// Resume not supported on Windows
-		}
-
-		return types.ResumeDownloadMsg{}
+		// Resume not supported on Windows
+		return types.ResumeDownloadMsg{JobID: jobID}
 	})
 }
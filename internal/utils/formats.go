@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,15 +14,15 @@ import (
 
 	"github.com/xdagiz/xytz/internal/config"
 	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/ytdlperr"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 var (
-	formatsCmd      *exec.Cmd
-	formatsMutex    sync.Mutex
-	formatsCanceled bool
+	formatsMutex  sync.Mutex
+	formatsCancel context.CancelFunc
 )
 
 func formatQuality(resolution string) string {
@@ -106,58 +108,54 @@ func getPreferredAudioFormat(formatsAny []any) (audioID string, audioLang string
 	return audioID, audioLang
 }
 
-func FetchFormats(url string) tea.Cmd {
+func FetchFormats(program *tea.Program, url string) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		cfg, err := config.Load()
 		if err != nil {
 			cfg = config.GetDefault()
 		}
-		ytDlpPath := cfg.YTDLPPath
-		if ytDlpPath == "" {
-			ytDlpPath = "yt-dlp"
-		}
-		cmd := exec.Command(ytDlpPath, "-J", url)
+		ytDlpPath := ytdlpBinary(cfg.YTDLPPath)
 
-		formatsMutex.Lock()
-		formatsCmd = cmd
-		formatsMutex.Unlock()
+		ctx, cancel := startFormats()
+		defer cancel()
 
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			errMsg := fmt.Sprintf("Format fetch error: %v", err)
-			return types.FormatResultMsg{Err: errMsg}
-		}
-
-		if err := cmd.Start(); err != nil {
-			formatsMutex.Lock()
-			formatsCmd = nil
-			formatsMutex.Unlock()
-			errMsg := fmt.Sprintf("Format fetch error: %v", err)
-			return types.FormatResultMsg{Err: errMsg}
-		}
+		var out []byte
+		for attempt := 1; ; attempt++ {
+			var stderrLines []string
+			out, stderrLines, err = runFormats(ctx, ytDlpPath, url, BaseArgs(cfg))
+			if ctx.Err() != nil {
+				return nil
+			}
 
-		out, err := io.ReadAll(stdout)
-		if err := stdout.Close(); err != nil {
-			log.Printf("failed to close formats stdout: %v", err)
-		}
+			if err != nil {
+				log.Printf("Format fetch error: %v", err)
+				return types.FormatResultMsg{Err: fmt.Sprintf("Format fetch error: %v", err)}
+			}
 
-		formatsMutex.Lock()
-		wasCancelled := formatsCanceled
-		formatsCanceled = false
-		formatsCmd = nil
-		formatsMutex.Unlock()
+			if len(out) > 0 {
+				break
+			}
 
-		if wasCancelled {
-			return nil
-		}
+			classified := ytdlperr.Classify(stderrLines)
+			if classified == nil {
+				return types.FormatResultMsg{Err: "No formats found"}
+			}
 
-		if err != nil {
-			log.Printf("Format fetch error: %v", err)
-			return types.FormatResultMsg{Err: fmt.Sprintf("Format fetch error: %v", err)}
-		}
+			if delay, ok := classified.Policy.Next(attempt); ok {
+				log.Printf("Format fetch failed (%s), retrying in %s", classified.Kind, delay)
+				program.Send(types.FetchRetryMsg{
+					Attempt:     attempt + 1,
+					MaxAttempts: classified.Policy.MaxAttempts,
+					Delay:       delay,
+					Err:         classified.Message,
+				})
+				if waitRetry(ctx, delay) {
+					return nil
+				}
+				continue
+			}
 
-		if len(out) == 0 {
-			return types.FormatResultMsg{Err: "No formats found"}
+			return types.FormatResultMsg{Err: "Format fetch error: " + classified.Message}
 		}
 
 		var data map[string]any
@@ -171,7 +169,6 @@ func FetchFormats(url string) tea.Cmd {
 		formatsAny, _ := data["formats"].([]any)
 		var videoFormats []list.Item
 		var audioFormats []list.Item
-		var thumbnailFormats []list.Item
 		var allFormats []list.Item
 
 		audioLanguages := make(map[string]bool)
@@ -195,6 +192,23 @@ func FetchFormats(url string) tea.Cmd {
 
 		showLanguage := len(audioLanguages) > 1
 
+		formatSizes := make(map[string]float64)
+		for _, fAny := range formatsAny {
+			f, ok := fAny.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			formatID, _ := f["format_id"].(string)
+			size, _ := f["filesize"].(float64)
+			if size == 0 {
+				size, _ = f["filesize_approx"].(float64)
+			}
+			if formatID != "" && size > 0 {
+				formatSizes[formatID] = size
+			}
+		}
+
 		for _, fAny := range formatsAny {
 			f, ok := fAny.(map[string]any)
 			if !ok {
@@ -225,7 +239,7 @@ func FetchFormats(url string) tea.Cmd {
 			formatType := ""
 			isVideoAudio := false
 			isAudioOnly := false
-			isThumbnail := ext == "mhtml"
+			isStoryboard := ext == "mhtml"
 
 			if vcodec != "none" && vcodec != "" {
 				if acodec != "none" && acodec != "" {
@@ -237,8 +251,8 @@ func FetchFormats(url string) tea.Cmd {
 			} else if acodec != "none" && acodec != "" {
 				formatType = "audio-only"
 				isAudioOnly = true
-			} else if isThumbnail {
-				formatType = "thumbnail"
+			} else if isStoryboard {
+				formatType = "storyboard"
 			} else {
 				formatType = "unknown"
 			}
@@ -248,7 +262,7 @@ func FetchFormats(url string) tea.Cmd {
 			if size == 0 {
 				size = sizeApprox
 			}
-			sizeStr := bytesToHuman(size)
+			sizeStr := FormatBytes(size)
 
 			lang := ""
 			if showLanguage {
@@ -266,7 +280,7 @@ func FetchFormats(url string) tea.Cmd {
 				if abr > 0 {
 					title = fmt.Sprintf("%s @%dk", ext, int(abr))
 				}
-			} else if isThumbnail {
+			} else if isStoryboard {
 				title = formatQuality(resolution)
 			} else {
 				quality := formatQuality(resolution)
@@ -291,6 +305,8 @@ func FetchFormats(url string) tea.Cmd {
 				Language:    lang,
 				Resolution:  resolution,
 				FormatType:  formatType,
+				Ext:         ext,
+				Bytes:       size,
 			}
 
 			allFormats = append(allFormats, formatItem)
@@ -301,8 +317,6 @@ func FetchFormats(url string) tea.Cmd {
 				}
 			} else if isAudioOnly {
 				audioFormats = append(audioFormats, formatItem)
-			} else if isThumbnail {
-				thumbnailFormats = append(thumbnailFormats, formatItem)
 			}
 		}
 
@@ -345,33 +359,74 @@ func FetchFormats(url string) tea.Cmd {
 					title = fmt.Sprintf("%s [%s]", title, audioLang)
 				}
 
+				sizeStr := "unknown size"
+				videoSize, audioSize := formatSizes[formatID], formatSizes[audioID]
+				if videoSize > 0 && audioSize > 0 {
+					sizeStr = FormatBytes(videoSize + audioSize)
+				}
+
 				preset := types.FormatItem{
 					FormatTitle: title,
 					FormatValue: formatID + "+" + audioID,
-					Size:        "unknown size",
+					Size:        sizeStr,
 					Language:    audioLang,
 					Resolution:  resolution,
 					FormatType:  "video-only+audio-only",
+					Ext:         "mp4",
+					Bytes:       videoSize + audioSize,
 				}
 
 				videoFormats = append(videoFormats, preset)
 			}
 		}
 
+		if HasFFmpeg(cfg.FFmpegPath) {
+			audioFormats = append(audioExtractionItems(videoInfo.Duration), audioFormats...)
+		}
+
 		return types.FormatResultMsg{
 			VideoFormats:     videoFormats,
 			AudioFormats:     audioFormats,
-			ThumbnailFormats: thumbnailFormats,
+			ThumbnailFormats: thumbnailItems(data),
+			SubtitleTracks:   subtitleTracks(data),
 			AllFormats:       allFormats,
+			FormatSizes:      formatSizes,
 			VideoInfo:        videoInfo,
 		}
 	})
 }
 
+// audioExtractionItems lists the "extract audio" presets shown above the raw
+// audio streams in the Audio tab. Sizes are estimated from the bitrate.
+func audioExtractionItems(duration float64) []list.Item {
+	var items []list.Item
+	for _, preset := range types.AudioPresets() {
+		size := "unknown size"
+		bytes := preset.EstimatedBytes(duration)
+		if bytes > 0 {
+			size = "~" + FormatBytes(bytes)
+		}
+
+		items = append(items, types.FormatItem{
+			FormatTitle: "Extract " + preset.Name(),
+			FormatValue: "bestaudio/best",
+			Size:        size,
+			FormatType:  "extract-audio",
+			Ext:         preset.Codec,
+			Bytes:       bytes,
+			Audio:       &preset,
+		})
+	}
+
+	return items
+}
+
 func extractVideoInfo(data map[string]any) types.VideoItem {
 	videoID, _ := data["id"].(string)
 	title, _ := data["title"].(string)
 	channel, _ := data["uploader"].(string)
+	uploader := channel
+	uploadDate, _ := data["upload_date"].(string)
 
 	var viewCount float64
 	if vc, ok := data["view_count"]; ok {
@@ -383,6 +438,22 @@ func extractVideoInfo(data map[string]any) types.VideoItem {
 		duration = parseFloat(d)
 	}
 
+	var chapters []types.Chapter
+	chaptersAny, _ := data["chapters"].([]any)
+	for _, cAny := range chaptersAny {
+		c, ok := cAny.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		chapterTitle, _ := c["title"].(string)
+		chapters = append(chapters, types.Chapter{
+			Title:     chapterTitle,
+			StartTime: parseFloat(c["start_time"]),
+			EndTime:   parseFloat(c["end_time"]),
+		})
+	}
+
 	viewsStr := FormatNumber(viewCount)
 	durationStr := FormatDuration(duration)
 
@@ -399,20 +470,64 @@ func extractVideoInfo(data map[string]any) types.VideoItem {
 		Views:      viewCount,
 		Duration:   duration,
 		Channel:    channel,
+		Uploader:   uploader,
+		UploadDate: uploadDate,
+		Chapters:   chapters,
 	}
 }
 
+func runFormats(ctx context.Context, ytDlpPath, url string, baseArgs []string) ([]byte, []string, error) {
+	cmd := exec.CommandContext(ctx, ytDlpPath, append(baseArgs, "-J", url)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	out, err := io.ReadAll(stdout)
+	if waitErr := cmd.Wait(); waitErr != nil {
+		log.Printf("yt-dlp format fetch failed: %v", waitErr)
+	}
+
+	var stderrLines []string
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			log.Printf("yt-dlp stderr: %s", line)
+			stderrLines = append(stderrLines, line)
+		}
+	}
+
+	return out, stderrLines, err
+}
+
+// startFormats cancels any format fetch still in flight and returns the
+// context for a new one.
+func startFormats() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	formatsMutex.Lock()
+	if formatsCancel != nil {
+		formatsCancel()
+	}
+	formatsCancel = cancel
+	formatsMutex.Unlock()
+
+	return ctx, cancel
+}
+
 func CancelFormats() tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		formatsMutex.Lock()
-
-		if formatsCmd != nil && formatsCmd.Process != nil {
-			formatsCanceled = true
-			if err := formatsCmd.Process.Kill(); err != nil {
-				log.Printf("Failed to kill formats process: %v", err)
-			}
+		if formatsCancel != nil {
+			formatsCancel()
+			formatsCancel = nil
 		}
-
 		formatsMutex.Unlock()
 		return types.CancelFormatsMsg{}
 	})
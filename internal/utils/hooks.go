@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/config"
+	"github.com/xdagiz/xytz/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	HookComplete = "on_complete"
+	HookError    = "on_error"
+	HookCancel   = "on_cancel"
+)
+
+func hookCommand(hooks config.Hooks, event string) string {
+	switch event {
+	case HookComplete:
+		return hooks.OnComplete
+	case HookError:
+		return hooks.OnError
+	case HookCancel:
+		return hooks.OnCancel
+	}
+
+	return ""
+}
+
+func hookEnv(event string, req types.DownloadRequest, filePaths []string, errMsg string) []string {
+	videoID := req.VideoID
+	if videoID == "" {
+		videoID = ExtractVideoID(req.URL)
+	}
+
+	filePath := ""
+	if len(filePaths) > 0 {
+		filePath = filePaths[len(filePaths)-1]
+	}
+
+	return append(os.Environ(),
+		"XYTZ_EVENT="+event,
+		"XYTZ_URL="+req.URL,
+		"XYTZ_VIDEO_ID="+videoID,
+		"XYTZ_TITLE="+req.Title,
+		"XYTZ_CHANNEL="+req.Channel,
+		"XYTZ_FORMAT_ID="+req.FormatID,
+		"XYTZ_FILE_PATH="+filePath,
+		"XYTZ_FILE_PATHS="+strings.Join(filePaths, "\n"),
+		"XYTZ_ERROR="+errMsg,
+	)
+}
+
+// RunHook runs the configured command for event in the background and
+// reports its outcome to the UI. It does nothing when no command is set.
+func RunHook(program *tea.Program, cfg *config.Config, event string, req types.DownloadRequest, filePaths []string, errMsg string) {
+	command := strings.TrimSpace(hookCommand(cfg.Hooks, event))
+	if command == "" {
+		return
+	}
+
+	go func() {
+		cmd := shellCommand(command)
+		cmd.Env = hookEnv(event, req, filePaths, errMsg)
+
+		out, err := cmd.CombinedOutput()
+		if output := strings.TrimSpace(string(out)); output != "" {
+			log.Printf("Hook %s output:\n%s", event, output)
+		}
+
+		msg := types.HookResultMsg{JobID: req.JobID, Event: event}
+		if err != nil {
+			log.Printf("Hook %s failed: %v", event, err)
+			msg.Err = err.Error()
+		} else {
+			log.Printf("Hook %s exited successfully", event)
+		}
+
+		program.Send(msg)
+	}()
+}
+
+// shellCommand runs command with sh -c, or cmd /C on Windows. args are
+// appended to the command line.
+func shellCommand(command string, args ...string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		for _, arg := range args {
+			command += ` "` + strings.ReplaceAll(arg, `"`, "'") + `"`
+		}
+		return exec.Command("cmd", "/C", command)
+	}
+
+	if len(args) > 0 {
+		command += ` "$@"`
+	}
+
+	return exec.Command("sh", append([]string{"-c", command, "sh"}, args...)...)
+}
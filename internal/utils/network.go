@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xdagiz/xytz/internal/config"
+)
+
+// NetworkArgs returns the yt-dlp options for the network section of the
+// config.
+func NetworkArgs(network config.Network) []string {
+	var args []string
+	if proxy := strings.TrimSpace(network.Proxy); proxy != "" {
+		args = append(args, "--proxy", proxy)
+	}
+
+	if address := strings.TrimSpace(network.SourceAddress); address != "" {
+		args = append(args, "--source-address", address)
+	}
+
+	switch {
+	case network.ForceIPv4 && network.ForceIPv6:
+		log.Printf("Warning: Both force_ipv4 and force_ipv6 are set, using IPv4")
+		args = append(args, "--force-ipv4")
+	case network.ForceIPv4:
+		args = append(args, "--force-ipv4")
+	case network.ForceIPv6:
+		args = append(args, "--force-ipv6")
+	}
+
+	if network.SocketTimeout > 0 {
+		args = append(args, "--socket-timeout", formatSeconds(network.SocketTimeout))
+	}
+
+	// yt-dlp refuses --max-sleep-interval without a smaller --sleep-interval.
+	if network.SleepInterval > 0 {
+		args = append(args, "--sleep-interval", formatSeconds(network.SleepInterval))
+		if network.MaxSleepInterval > network.SleepInterval {
+			args = append(args, "--max-sleep-interval", formatSeconds(network.MaxSleepInterval))
+		} else if network.MaxSleepInterval > 0 {
+			log.Printf("Warning: max_sleep_interval must be larger than sleep_interval, ignoring it")
+		}
+	} else if network.MaxSleepInterval > 0 {
+		log.Printf("Warning: max_sleep_interval needs sleep_interval, ignoring it")
+	}
+
+	if network.SleepRequests > 0 {
+		args = append(args, "--sleep-requests", formatSeconds(network.SleepRequests))
+	}
+
+	return args
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
+
+// httpClient returns a client that follows the same network settings as
+// yt-dlp, for the requests xytz makes itself.
+func httpClient(network config.Network) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy := strings.TrimSpace(network.Proxy); proxy != "" {
+		if proxyURL, err := url.Parse(proxy); err == nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		} else {
+			log.Printf("Warning: Invalid proxy %q: %v", proxy, err)
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if network.SocketTimeout > 0 {
+		dialer.Timeout = time.Duration(network.SocketTimeout * float64(time.Second))
+	}
+	if ip := net.ParseIP(strings.TrimSpace(network.SourceAddress)); ip != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	tcp := "tcp"
+	if network.ForceIPv4 {
+		tcp = "tcp4"
+	} else if network.ForceIPv6 {
+		tcp = "tcp6"
+	}
+
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, tcp, addr)
+	}
+
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/xdagiz/xytz/internal/config"
+)
+
+// OSC 9;4 progress states.
+const (
+	ProgressClear  = 0
+	ProgressNormal = 1
+	ProgressError  = 2
+	ProgressPaused = 4
+)
+
+// terminalOutput serializes writes to the terminal, so that a notification
+// never lands in the middle of a frame.
+type terminalOutput struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (t *terminalOutput) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.File.Write(p)
+}
+
+// TerminalOutput is the output of the program. Escape sequences for
+// notifications go through it too.
+var TerminalOutput = &terminalOutput{File: os.Stdout}
+
+// writeTerminal sends an escape sequence straight to the terminal. Inside
+// tmux the sequence is wrapped in a passthrough so it reaches the outer
+// terminal.
+func writeTerminal(seq string) {
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+
+	writeRaw(seq)
+}
+
+func writeRaw(seq string) {
+	if _, err := TerminalOutput.Write([]byte(seq)); err != nil {
+		log.Printf("Failed to write to the terminal: %v", err)
+	}
+}
+
+// oscText strips the characters that would end or split an OSC sequence.
+func oscText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ';':
+			return ','
+		case r < 0x20 || r == 0x7f:
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// Notify reports a finished download through every notification enabled in
+// the config.
+func Notify(cfg config.Notifications, title, message string) {
+	title = oscText(title)
+	message = oscText(message)
+
+	if cfg.OSC9 {
+		writeTerminal(fmt.Sprintf("\x1b]9;%s: %s\x07", title, message))
+	}
+
+	if cfg.OSC777 {
+		writeTerminal(fmt.Sprintf("\x1b]777;notify;%s;%s\x07", title, message))
+	}
+
+	if cfg.Bell {
+		writeRaw("\a")
+	}
+
+	command := strings.TrimSpace(cfg.Command)
+	if command == "" {
+		return
+	}
+
+	go func() {
+		out, err := shellCommand(command, title, message).CombinedOutput()
+		if err != nil {
+			log.Printf("Notification command failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+	}()
+}
+
+// SetTerminalProgress reports download progress to the terminal with
+// OSC 9;4, which terminals show in the tab title or taskbar.
+func SetTerminalProgress(state, percent int) {
+	if state == ProgressClear {
+		writeTerminal("\x1b]9;4;0;\x07")
+		return
+	}
+
+	writeTerminal(fmt.Sprintf("\x1b]9;4;%d;%d\x07", state, min(max(percent, 0), 100)))
+}
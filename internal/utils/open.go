@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"log"
+	"os/exec"
+	"runtime"
+)
+
+// OpenPath opens a URL, file or directory with the platform's default handler.
+func OpenPath(target string) {
+	go func() {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+		case "darwin":
+			cmd = exec.Command("open", target)
+		default:
+			cmd = exec.Command("xdg-open", target)
+		}
+
+		if err := cmd.Start(); err != nil {
+			log.Printf("Failed to open %s: %v", target, err)
+			return
+		}
+
+		if err := cmd.Wait(); err != nil {
+			log.Printf("Opener for %s exited with error: %v", target, err)
+		}
+	}()
+}
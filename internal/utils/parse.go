@@ -9,6 +9,12 @@ import (
 	"github.com/xdagiz/xytz/internal/types"
 )
 
+// IsPlaylistURL reports whether yt-dlp downloads a whole playlist for url:
+// playlist pages, and watch URLs that carry a list parameter.
+func IsPlaylistURL(url string) bool {
+	return strings.Contains(url, "/playlist?list=") || strings.Contains(url, "&list=")
+}
+
 func ExtractVideoID(url string) string {
 	if strings.Contains(url, "youtube.com/watch") && strings.Contains(url, "v=") {
 		parts := strings.Split(url, "v=")
@@ -129,6 +135,8 @@ func ParseVideoItem(line string) (types.VideoItem, error) {
 			channel = playlistUploader
 		}
 	}
+	uploader := channel
+	uploadDate, _ := data["upload_date"].(string)
 
 	var viewCountFloat float64
 	if vc, ok := data["view_count"]; ok {
@@ -161,6 +169,13 @@ func ParseVideoItem(line string) (types.VideoItem, error) {
 		Views:      viewCountFloat,
 		Duration:   durationFloat,
 		Channel:    channel,
+		Uploader:   uploader,
+		UploadDate: uploadDate,
+	}
+
+	if idx, ok := data["playlist_index"]; ok {
+		videoItem.PlaylistIndex = int(parseFloat(idx))
+		videoItem.PlaylistTitle, _ = data["playlist_title"].(string)
 	}
 
 	return videoItem, nil
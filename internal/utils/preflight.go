@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/config"
+	"github.com/xdagiz/xytz/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mediaExts are the extensions a finished download can have. Partial files
+// and sidecars (thumbnails, subtitles, info json) do not count as duplicates.
+var mediaExts = []string{".mp4", ".mkv", ".webm", ".mov", ".m4v", ".avi", ".flv", ".3gp", ".m4a", ".mp3", ".opus", ".ogg", ".flac", ".wav", ".aac"}
+
+// EstimateSize adds up the sizes of the streams in a format selector such as
+// "137+140". Only the first alternative of "a/b" selectors is considered. It
+// returns 0 when any of the streams has no known size.
+func EstimateSize(formatID string, sizes map[string]float64) float64 {
+	first, _, _ := strings.Cut(formatID, "/")
+
+	var total float64
+	for _, part := range strings.Split(first, "+") {
+		size := sizes[strings.TrimSpace(part)]
+		if size <= 0 {
+			return 0
+		}
+		total += size
+	}
+
+	return total
+}
+
+// Preflight checks that a download fits on the destination filesystem and
+// does not clash with files that are already there. reqs holds one request
+// per video of a batch, or a single one; free space is checked once for all
+// of them. Playlists are only checked for space, as their file names are not
+// known before yt-dlp runs.
+func Preflight(msg types.StartDownloadMsg, reqs []types.DownloadRequest, videos []types.VideoItem) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		cfg, err := config.Load()
+		if err != nil {
+			cfg = config.GetDefault()
+		}
+
+		result := types.PreflightMsg{Start: msg}
+		if len(reqs) == 0 {
+			return result
+		}
+
+		result.EstimatedBytes = msg.EstimatedBytes
+		for i, req := range reqs {
+			req = ResolveRequest(req, cfg)
+			if i == 0 {
+				result.OutputDir = req.OutputDir
+				if free, err := freeSpace(existingParent(filepath.Dir(OutputPath(req.OutputDir, req.OutputTemplate)))); err == nil {
+					result.FreeBytes = float64(free)
+				}
+			}
+
+			if msg.EstimatedBytes == 0 {
+				result.EstimatedBytes += EstimateSize(req.FormatID, msg.StreamSizes)
+			}
+
+			// Playlist file names are only known once yt-dlp runs, and clips
+			// are named after their section, so neither can clash.
+			if req.Playlist || len(req.Sections) > 0 || i >= len(videos) {
+				continue
+			}
+
+			duplicates, keepBoth, err := findDuplicates(req, videos[i])
+			if err != nil {
+				result.Err = "Failed to check the destination: " + err.Error()
+				continue
+			}
+
+			if len(duplicates) > 0 {
+				result.Duplicates = append(result.Duplicates, duplicates...)
+				result.DuplicateURLs = append(result.DuplicateURLs, req.URL)
+				if len(reqs) == 1 {
+					result.KeepBoth = keepBoth
+				}
+			}
+		}
+
+		return result
+	})
+}
+
+// findDuplicates lists the media files in the destination of a resolved
+// request that have the same title or video ID, along with an output
+// template that keeps the new file next to them.
+func findDuplicates(req types.DownloadRequest, video types.VideoItem) ([]string, string, error) {
+	target := RenderTemplate(OutputPath(req.OutputDir, req.OutputTemplate), TemplateFields(video, "xytz-ext"))
+	target = strings.TrimSuffix(target, ".xytz-ext")
+	dir := filepath.Dir(target)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("cannot read %s: %v", dir, err)
+	}
+
+	var duplicates []string
+	stem := filepath.Base(target)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !slices.Contains(mediaExts, strings.ToLower(filepath.Ext(name))) {
+			continue
+		}
+
+		sameTitle := strings.TrimSuffix(name, filepath.Ext(name)) == stem
+		sameID := len(video.ID) >= 6 && strings.Contains(name, video.ID)
+		if sameTitle || sameID {
+			duplicates = append(duplicates, filepath.Join(dir, name))
+		}
+	}
+
+	if len(duplicates) == 0 {
+		return nil, "", nil
+	}
+
+	return duplicates, keepBothTemplate(req.OutputTemplate, entries, stem), nil
+}
+
+// keepBothTemplate numbers the new file after the existing ones, "Title (2)",
+// "Title (3)" and so on.
+func keepBothTemplate(tmpl string, entries []os.DirEntry, stem string) string {
+	taken := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		taken[strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))] = true
+	}
+
+	n := 2
+	for taken[fmt.Sprintf("%s (%d)", stem, n)] {
+		n++
+	}
+
+	return SuffixTemplate(tmpl, fmt.Sprintf(" (%d)", n))
+}
+
+// existingParent returns the closest directory of path that exists, since the
+// download directory is only created when the download starts.
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
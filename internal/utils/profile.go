@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"slices"
+
+	"github.com/xdagiz/xytz/internal/config"
+	"github.com/xdagiz/xytz/internal/types"
+)
+
+// applyProfile fills the parts of req that the profile controls. The format
+// and download options are picked in the UI, everything else is set here so
+// the unfinished record keeps the resolved values.
+func applyProfile(req *types.DownloadRequest, profile config.Profile, cfg *config.Config) {
+	if profile.OutputDir != "" && req.OutputDir == "" {
+		req.OutputDir = cfg.ExpandPath(profile.OutputDir)
+	}
+
+	if profile.OutputTemplate != "" && req.OutputTemplate == "" {
+		req.OutputTemplate = profile.OutputTemplate
+	}
+
+	// Audio picked in the Audio tab, thumbnails and subtitle-only downloads
+	// keep their own output.
+	subtitlesOnly := req.Subtitles != nil && req.Subtitles.Mode == types.SubtitleOnly
+	if profile.AudioFormat != "" && req.AudioFormat == "" && req.Thumbnail == nil && !subtitlesOnly {
+		req.AudioFormat = profile.AudioFormat
+		req.AudioQuality = profile.AudioQuality
+	}
+
+	if req.Thumbnail == nil {
+		req.ExtraArgs = slices.Clone(profile.ExtraArgs)
+	}
+}
@@ -2,26 +2,97 @@ package utils
 
 import (
 	"bufio"
+	"encoding/json"
 	"io"
-	"log"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/xdagiz/xytz/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// progressPrefix marks the lines produced by ProgressTemplate so they can be
+// told apart from the rest of yt-dlp's output.
+const progressPrefix = "[xytz] "
+
+// ProgressTemplate makes yt-dlp report download progress as one JSON object
+// per line. Fields that are not available are rendered as "NA" by yt-dlp.
+const ProgressTemplate = "download:" + progressPrefix + `{"progress":%(progress)j,"id":%(info.id)j,"ext":%(info.ext)j,"format_id":%(info.format_id)j,"vcodec":%(info.vcodec)j,"acodec":%(info.acodec)j}`
+
+var (
+	playlistItemRegex  = regexp.MustCompile(`\[download\] Downloading (?:item|video) (\d+) of (\d+)`)
+	extractingURLRegex = regexp.MustCompile(`Extracting URL:\s*(\S+)`)
+	percentRegex       = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+	speedRegex         = regexp.MustCompile(`(\d+(?:\.\d+)?[KMG]?i?B/s)`)
+	etaRegex           = regexp.MustCompile(`ETA\s+(\d+:\d+(?::\d+)?)`)
+	destinationRegex   = regexp.MustCompile(`Destination:\s*(.+)`)
+	formatRegex        = regexp.MustCompile(`(?:format|format_id)\s+(\d+)`)
+	fragmentRegex      = regexp.MustCompile(`\(frag (\d+)/(\d+)\)`)
+	postProcessRegex   = regexp.MustCompile(`^\[(\w+)\]`)
+	mergerRegex        = regexp.MustCompile(`Merging formats into "(.+)"`)
 )
 
+// postProcessPhases maps yt-dlp post-processor tags to download phases.
+var postProcessPhases = map[string]types.DownloadPhase{
+	"Merger":              types.PhaseMerging,
+	"VideoConvertor":      types.PhaseMerging,
+	"VideoRemuxer":        types.PhaseMerging,
+	"ExtractAudio":        types.PhaseExtractingAudio,
+	"EmbedSubtitle":       types.PhaseEmbeddingSubtitles,
+	"SubtitlesConvertor":  types.PhaseConvertingSubtitles,
+	"EmbedThumbnail":      types.PhaseEmbeddingThumbnail,
+	"ThumbnailsConvertor": types.PhaseConvertingThumbnail,
+	"Metadata":            types.PhaseAddingMetadata,
+	"FixupM3u8":           types.PhaseFixup,
+	"FixupM4a":            types.PhaseFixup,
+	"FixupStretched":      types.PhaseFixup,
+	"FixupTimestamp":      types.PhaseFixup,
+	"FixupDuration":       types.PhaseFixup,
+	"FixupDuplicateMoov":  types.PhaseFixup,
+	"SponsorBlock":        types.PhaseSponsorBlock,
+	"ModifyChapters":      types.PhaseSponsorBlock,
+}
+
+type progressLine struct {
+	Progress struct {
+		Status             string  `json:"status"`
+		DownloadedBytes    float64 `json:"downloaded_bytes"`
+		TotalBytes         float64 `json:"total_bytes"`
+		TotalBytesEstimate float64 `json:"total_bytes_estimate"`
+		Speed              float64 `json:"speed"`
+		ETA                float64 `json:"eta"`
+		FragmentIndex      int     `json:"fragment_index"`
+		FragmentCount      int     `json:"fragment_count"`
+		Filename           string  `json:"filename"`
+	} `json:"progress"`
+	ID       string `json:"id"`
+	Ext      string `json:"ext"`
+	FormatID string `json:"format_id"`
+	VCodec   string `json:"vcodec"`
+	ACodec   string `json:"acodec"`
+}
+
 type ProgressParser struct {
-	regex              *regexp.Regexp
+	mu                 sync.Mutex
+	jobID              int
 	currentFormat      string
 	currentDestination string
+	itemIndex          int
+	itemTotal          int
+	itemID             string
+	itemStatus         types.PlaylistItemStatus
+	errorLines         []string
 }
 
-func NewProgressParser() *ProgressParser {
-	return &ProgressParser{
-		regex: regexp.MustCompile(`(\d+(?:\.\d+)?)%`),
-	}
+func NewProgressParser(jobID int) *ProgressParser {
+	return &ProgressParser{jobID: jobID}
 }
 
-func (p *ProgressParser) ReadPipe(pipe io.Reader, sendProgress func(float64, string, string, string, string)) {
+func (p *ProgressParser) ReadPipe(pipe io.Reader, send func(tea.Msg)) {
 	reader := bufio.NewReader(pipe)
 	var lineBuilder strings.Builder
 
@@ -29,34 +100,16 @@ func (p *ProgressParser) ReadPipe(pipe io.Reader, sendProgress func(float64, str
 		r, _, err := reader.ReadRune()
 		if err != nil {
 			if lineBuilder.Len() > 0 {
-				line := lineBuilder.String()
-				percent, speed, eta, status, destination := p.ParseLine(line)
-				if strings.Contains(line, "[download]") || percent > 0 || speed != "" || eta != "" {
-					sendProgress(percent, speed, eta, status, destination)
-				}
+				p.handleLine(lineBuilder.String(), send)
 			}
 			break
 		}
 
 		switch r {
 		// TODO: test this on windows and remove if not needed
-		case '\r':
+		case '\r', '\n':
 			if lineBuilder.Len() > 0 {
-				line := lineBuilder.String()
-				percent, speed, eta, status, destination := p.ParseLine(line)
-				if strings.Contains(line, "[download]") || percent > 0 || speed != "" || eta != "" {
-					log.Printf("Progress parsed (\\r): %.2f%%, speed: %s, eta: %s, status: %s, destination: %s, line: %s", percent, speed, eta, status, destination, line)
-					sendProgress(percent, speed, eta, status, destination)
-				}
-				lineBuilder.Reset()
-			}
-		case '\n':
-			if lineBuilder.Len() > 0 {
-				line := lineBuilder.String()
-				percent, speed, eta, status, destination := p.ParseLine(line)
-				if strings.Contains(line, "[download]") || percent > 0 || speed != "" || eta != "" {
-					sendProgress(percent, speed, eta, status, destination)
-				}
+				p.handleLine(lineBuilder.String(), send)
 				lineBuilder.Reset()
 			}
 		default:
@@ -65,37 +118,218 @@ func (p *ProgressParser) ReadPipe(pipe io.Reader, sendProgress func(float64, str
 	}
 }
 
-func (p *ProgressParser) ParseLine(line string) (percent float64, speed, eta, status, destination string) {
-	percentPatterns := []*regexp.Regexp{
-		regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`),
-		regexp.MustCompile(`\[download\]\s+(\d+(?:\.\d+)?)%`),
+func (p *ProgressParser) handleLine(line string, send func(tea.Msg)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if strings.HasPrefix(line, "ERROR:") {
+		p.errorLines = append(p.errorLines, line)
+	}
+
+	for _, msg := range p.parsePlaylistLine(line) {
+		send(msg)
 	}
 
-	for _, pattern := range percentPatterns {
-		percentMatch := pattern.FindStringSubmatch(line)
-		if len(percentMatch) > 1 {
-			if pr, err := strconv.ParseFloat(percentMatch[1], 64); err == nil {
-				percent = pr
-				break
+	if strings.HasPrefix(line, progressPrefix) {
+		if msg, ok := p.parseTemplateLine(strings.TrimPrefix(line, progressPrefix)); ok {
+			send(msg)
+		}
+		return
+	}
+
+	if match := postProcessRegex.FindStringSubmatch(line); len(match) > 1 {
+		if phase, ok := postProcessPhases[match[1]]; ok {
+			if merge := mergerRegex.FindStringSubmatch(line); len(merge) > 1 {
+				p.currentDestination = merge[1]
+			} else if dest := destinationRegex.FindStringSubmatch(line); len(dest) > 1 {
+				p.currentDestination = strings.TrimSpace(dest[1])
 			}
+
+			send(types.ProgressMsg{
+				JobID:       p.jobID,
+				Percent:     100,
+				Status:      "[" + match[1] + "]",
+				Destination: p.currentDestination,
+				Phase:       phase,
+			})
+			return
+		}
+	}
+
+	percent, speed, eta, status, destination := p.ParseLine(line)
+	if strings.Contains(line, "[download]") || percent > 0 || speed != "" || eta != "" {
+		msg := types.ProgressMsg{
+			JobID:       p.jobID,
+			Percent:     percent,
+			Speed:       speed,
+			Eta:         eta,
+			Status:      status,
+			Destination: destination,
+			Phase:       types.PhaseDownloading,
+		}
+
+		if match := fragmentRegex.FindStringSubmatch(line); len(match) > 2 {
+			msg.FragmentIndex, _ = strconv.Atoi(match[1])
+			msg.FragmentCount, _ = strconv.Atoi(match[2])
+		}
+
+		send(msg)
+	}
+}
+
+// parseTemplateLine decodes a line emitted through ProgressTemplate. yt-dlp
+// writes "NA" for missing values, so those are cleared before decoding.
+func (p *ProgressParser) parseTemplateLine(data string) (types.ProgressMsg, bool) {
+	data = strings.ReplaceAll(data, `:"NA"`, ":null")
+
+	var line progressLine
+	if err := json.Unmarshal([]byte(data), &line); err != nil {
+		return types.ProgressMsg{}, false
+	}
+
+	progress := line.Progress
+	total := progress.TotalBytes
+	if total == 0 {
+		total = progress.TotalBytesEstimate
+	}
+
+	var percent float64
+	if total > 0 {
+		percent = progress.DownloadedBytes / total * 100
+	} else if progress.FragmentCount > 0 {
+		percent = float64(progress.FragmentIndex) / float64(progress.FragmentCount) * 100
+	}
+	if progress.Status == "finished" {
+		percent = 100
+	}
+
+	if progress.Filename != "" {
+		p.currentDestination = progress.Filename
+	}
+
+	switch {
+	case line.VCodec != "" && line.VCodec != "none":
+		p.currentFormat = "video"
+	case line.ACodec != "" && line.ACodec != "none":
+		p.currentFormat = "audio"
+	case progress.Filename != "":
+		p.currentFormat = extractFormatFromDestination(progress.Filename)
+	}
+
+	status := "[download]"
+	if p.currentFormat != "" {
+		status += " " + p.currentFormat
+	}
+
+	msg := types.ProgressMsg{
+		JobID:           p.jobID,
+		Percent:         percent,
+		Status:          status,
+		Destination:     p.currentDestination,
+		DownloadedBytes: progress.DownloadedBytes,
+		TotalBytes:      total,
+		FragmentIndex:   progress.FragmentIndex,
+		FragmentCount:   progress.FragmentCount,
+		VideoID:         line.ID,
+		Ext:             line.Ext,
+		FormatID:        line.FormatID,
+		Phase:           types.PhaseDownloading,
+	}
+
+	if progress.Speed > 0 {
+		msg.Speed = FormatBytes(progress.Speed) + "/s"
+	}
+	if progress.ETA > 0 {
+		msg.Eta = FormatDuration(progress.ETA)
+	}
+
+	return msg, true
+}
+
+// Errors returns the ERROR lines yt-dlp printed so far.
+func (p *ProgressParser) Errors() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.errorLines)
+}
+
+func (p *ProgressParser) itemMsg(status types.PlaylistItemStatus, errMsg string) types.PlaylistItemMsg {
+	p.itemStatus = status
+	return types.PlaylistItemMsg{
+		JobID:   p.jobID,
+		Index:   p.itemIndex,
+		Total:   p.itemTotal,
+		VideoID: p.itemID,
+		Status:  status,
+		Err:     errMsg,
+	}
+}
+
+func (p *ProgressParser) finishItem() []tea.Msg {
+	if p.itemIndex == 0 || p.itemStatus != types.ItemDownloading {
+		return nil
+	}
+
+	return []tea.Msg{p.itemMsg(types.ItemDone, "")}
+}
+
+func (p *ProgressParser) parsePlaylistLine(line string) []tea.Msg {
+	if match := playlistItemRegex.FindStringSubmatch(line); len(match) > 2 {
+		msgs := p.finishItem()
+
+		p.itemIndex, _ = strconv.Atoi(match[1])
+		p.itemTotal, _ = strconv.Atoi(match[2])
+		p.itemID = ""
+		p.currentDestination = ""
+		p.currentFormat = ""
+
+		return append(msgs, p.itemMsg(types.ItemDownloading, ""))
+	}
+
+	if p.itemIndex == 0 {
+		return nil
+	}
+
+	switch {
+	case strings.Contains(line, "Extracting URL:"):
+		if match := extractingURLRegex.FindStringSubmatch(line); len(match) > 1 {
+			if id := ExtractVideoID(match[1]); id != "" {
+				p.itemID = id
+				return []tea.Msg{p.itemMsg(p.itemStatus, "")}
+			}
+		}
+	case strings.Contains(line, "has already been downloaded"),
+		strings.Contains(line, "has already been recorded in the archive"):
+		return []tea.Msg{p.itemMsg(types.ItemSkipped, "")}
+	case strings.HasPrefix(line, "ERROR:"):
+		if p.itemStatus == types.ItemDownloading {
+			return []tea.Msg{p.itemMsg(types.ItemFailed, strings.TrimSpace(strings.TrimPrefix(line, "ERROR:")))}
+		}
+	case strings.Contains(line, "[download] Finished downloading playlist"):
+		return p.finishItem()
+	}
+
+	return nil
+}
+
+func (p *ProgressParser) ParseLine(line string) (percent float64, speed, eta, status, destination string) {
+	if match := percentRegex.FindStringSubmatch(line); len(match) > 1 {
+		if pr, err := strconv.ParseFloat(match[1], 64); err == nil {
+			percent = pr
 		}
 	}
 
-	speedPattern := regexp.MustCompile(`(\d+(?:\.\d+)?[KMG]?i?B/s)`)
-	speedMatch := speedPattern.FindStringSubmatch(line)
-	if len(speedMatch) > 1 {
-		speed = speedMatch[1]
+	if match := speedRegex.FindStringSubmatch(line); len(match) > 1 {
+		speed = match[1]
 	}
 
-	etaPattern := regexp.MustCompile(`ETA\s+(\d+:\d+(?::\d+)?)`)
-	etaMatch := etaPattern.FindStringSubmatch(line)
-	if len(etaMatch) > 1 {
-		eta = etaMatch[1]
+	if match := etaRegex.FindStringSubmatch(line); len(match) > 1 {
+		eta = match[1]
 	}
 
 	if strings.Contains(line, "[download] Destination:") {
-		destPattern := regexp.MustCompile(`Destination:\s*(.+)`)
-		if match := destPattern.FindStringSubmatch(line); len(match) > 1 {
+		if match := destinationRegex.FindStringSubmatch(line); len(match) > 1 {
 			p.currentDestination = strings.TrimSpace(match[1])
 		}
 
@@ -104,8 +338,7 @@ func (p *ProgressParser) ParseLine(line string) (percent float64, speed, eta, st
 		}
 	}
 
-	formatPattern := regexp.MustCompile(`(?:format|format_id)\s+(\d+)`)
-	if match := formatPattern.FindStringSubmatch(line); len(match) > 1 {
+	if match := formatRegex.FindStringSubmatch(line); len(match) > 1 {
 		p.currentFormat = "format " + match[1]
 	}
 
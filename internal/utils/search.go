@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"net/url"
@@ -13,24 +14,24 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/xdagiz/xytz/internal/config"
 	"github.com/xdagiz/xytz/internal/types"
+	"github.com/xdagiz/xytz/internal/ytdlperr"
 )
 
 var (
-	searchCmd      *exec.Cmd
-	searchMutex    sync.Mutex
-	searchCanceled bool
+	searchMutex  sync.Mutex
+	searchCancel context.CancelFunc
 )
 
-func executeYTDLP(searchURL string) any {
+func executeYTDLP(program *tea.Program, searchURL string) any {
 	cfg, err := config.Load()
 	if err != nil {
 		cfg = config.GetDefault()
 	}
 
-	ytDlpPath := cfg.YTDLPPath
-	if ytDlpPath == "" {
-		ytDlpPath = "yt-dlp"
-	}
+	ytDlpPath := ytdlpBinary(cfg.YTDLPPath)
+
+	ctx, cancel := startSearch()
+	defer cancel()
 
 	if err := exec.Command(ytDlpPath, "--version").Run(); err != nil {
 		if err.Error() == "exec: \""+ytDlpPath+"\": executable file not found in $PATH" ||
@@ -44,37 +45,80 @@ func executeYTDLP(searchURL string) any {
 		return types.SearchResultMsg{Err: errMsg}
 	}
 
-	playlistItems := fmt.Sprintf("1:%d", cfg.SearchLimit)
-	cmd := exec.Command(
-		ytDlpPath,
+	for attempt := 1; ; attempt++ {
+		videos, stderrLines, err := runSearch(ctx, ytDlpPath, searchURL, cfg.SearchLimit, BaseArgs(cfg))
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			return types.SearchResultMsg{Err: err.Error()}
+		}
+
+		if len(videos) > 0 {
+			return types.SearchResultMsg{Videos: videos}
+		}
+
+		classified := ytdlperr.Classify(stderrLines)
+		if classified == nil {
+			return types.SearchResultMsg{}
+		}
+
+		if delay, ok := classified.Policy.Next(attempt); ok {
+			log.Printf("Search failed (%s), retrying in %s", classified.Kind, delay)
+			program.Send(types.FetchRetryMsg{
+				Attempt:     attempt + 1,
+				MaxAttempts: classified.Policy.MaxAttempts,
+				Delay:       delay,
+				Err:         classified.Message,
+			})
+			if waitRetry(ctx, delay) {
+				return nil
+			}
+			continue
+		}
+
+		return types.SearchResultMsg{Err: searchErrorMessage(classified, searchURL)}
+	}
+}
+
+func searchErrorMessage(classified *ytdlperr.Error, searchURL string) string {
+	if classified.Kind != ytdlperr.NotFound {
+		return classified.Message
+	}
+
+	if strings.Contains(searchURL, "/playlist?list=") {
+		return "Playlist not found"
+	}
+
+	return "Channel not found"
+}
+
+func runSearch(ctx context.Context, ytDlpPath, searchURL string, limit int, baseArgs []string) ([]list.Item, []string, error) {
+	playlistItems := fmt.Sprintf("1:%d", limit)
+	args := append(baseArgs,
 		"--flat-playlist",
 		"--dump-json",
 		"--playlist-items", playlistItems,
 		searchURL,
 	)
-
-	searchMutex.Lock()
-	searchCmd = cmd
-	searchMutex.Unlock()
+	cmd := exec.CommandContext(ctx, ytDlpPath, args...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		errMsg := fmt.Sprintf("failed to get stdout pipe: %v", err)
-		return types.SearchResultMsg{Err: errMsg}
+		return nil, nil, fmt.Errorf("failed to get stdout pipe: %v", err)
 	}
 	defer stdout.Close()
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		errMsg := fmt.Sprintf("failed to get stderr pipe: %v", err)
-		return types.SearchResultMsg{Err: errMsg}
+		return nil, nil, fmt.Errorf("failed to get stderr pipe: %v", err)
 	}
 
 	defer stderr.Close()
 
 	if err := cmd.Start(); err != nil {
-		errMsg := fmt.Sprintf("failed to start search: %v", err)
-		return types.SearchResultMsg{Err: errMsg}
+		return nil, nil, fmt.Errorf("failed to start search: %v", err)
 	}
 
 	var videos []list.Item
@@ -82,7 +126,9 @@ func executeYTDLP(searchURL string) any {
 	scanner := bufio.NewScanner(stdout)
 	stderrScanner := bufio.NewScanner(stderr)
 	stderrLines := []string{}
+	stderrDone := make(chan struct{})
 	go func() {
+		defer close(stderrDone)
 		for stderrScanner.Scan() {
 			line := stderrScanner.Text()
 			stderrLines = append(stderrLines, line)
@@ -111,45 +157,31 @@ func executeYTDLP(searchURL string) any {
 		log.Printf("Scanner error: %v", err)
 	}
 
+	<-stderrDone
 	if err := cmd.Wait(); err != nil {
 		log.Printf("yt-dlp command failed: %v", err)
 		log.Printf("stderr output: %v", stderrLines)
 	}
 
-	searchMutex.Lock()
-	wasCancelled := searchCanceled
-	searchCanceled = false
-	searchCmd = nil
-	searchMutex.Unlock()
+	return videos, stderrLines, nil
+}
 
-	if wasCancelled {
-		return nil
-	}
+// startSearch cancels any search still in flight, so that its result cannot
+// replace the new one, and returns the context for the new search.
+func startSearch() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
 
-	var errMsg string
-	if len(videos) == 0 {
-		for _, line := range stderrLines {
-			if strings.Contains(line, "[Errno 101]") || strings.Contains(line, "[Errno -3]") {
-				errMsg = "Please Check Your Internet connection"
-			} else if strings.Contains(line, "HTTP Error 404") || strings.Contains(line, "Requested entity was not found") {
-				if strings.Contains(searchURL, "/playlist?list=") {
-					errMsg = "Playlist not found"
-				} else {
-					errMsg = "Channel not found"
-				}
-			} else if strings.Contains(line, "Private playlist") || strings.Contains(line, "This playlist is private") {
-				errMsg = "This playlist is private"
-			} else if strings.Contains(line, "Playlist does not exist") {
-				errMsg = "Playlist does not exist"
-			}
-		}
-		return types.SearchResultMsg{Err: errMsg}
-	} else {
-		return types.SearchResultMsg{Videos: videos}
+	searchMutex.Lock()
+	if searchCancel != nil {
+		searchCancel()
 	}
+	searchCancel = cancel
+	searchMutex.Unlock()
+
+	return ctx, cancel
 }
 
-func PerformSearch(query, sortParam string) tea.Cmd {
+func PerformSearch(program *tea.Program, query, sortParam string) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		query = strings.TrimSpace(query)
 
@@ -162,12 +194,12 @@ func PerformSearch(query, sortParam string) tea.Cmd {
 		} else {
 			encodedQuery := url.QueryEscape(query)
 			searchURL := "https://www.youtube.com/results?search_query=" + encodedQuery + "&sp=" + sortParam
-			return executeYTDLP(searchURL)
+			return executeYTDLP(program, searchURL)
 		}
 	})
 }
 
-func PerformChannelSearch(input string) tea.Cmd {
+func PerformChannelSearch(program *tea.Program, input string) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		var channelURL string
 
@@ -183,11 +215,11 @@ func PerformChannelSearch(input string) tea.Cmd {
 			channelURL = "https://www.youtube.com/@" + encodedChannel + "/videos"
 		}
 
-		return executeYTDLP(channelURL)
+		return executeYTDLP(program, channelURL)
 	})
 }
 
-func PerformPlaylistSearch(query string) tea.Cmd {
+func PerformPlaylistSearch(program *tea.Program, query string) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		var playlistURL string
 
@@ -208,21 +240,17 @@ func PerformPlaylistSearch(query string) tea.Cmd {
 			playlistURL = "https://www.youtube.com/playlist?list=" + query
 		}
 
-		return executeYTDLP(playlistURL)
+		return executeYTDLP(program, playlistURL)
 	})
 }
 
 func CancelSearch() tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		searchMutex.Lock()
-
-		if searchCmd != nil && searchCmd.Process != nil {
-			searchCanceled = true
-			if err := searchCmd.Process.Kill(); err != nil {
-				log.Printf("Failed to kill search process: %v", err)
-			}
+		if searchCancel != nil {
+			searchCancel()
+			searchCancel = nil
 		}
-
 		searchMutex.Unlock()
 		return types.CancelSearchMsg{}
 	})
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/types"
+)
+
+// ParseSections reads a comma separated list of time ranges ("12:30-15:10",
+// "1:02:00-" for the rest of the video) and chapter numbers ("#3"). Ranges
+// are checked against duration when it is known.
+func ParseSections(input string, duration float64, chapters []types.Chapter) ([]types.Section, error) {
+	var sections []types.Section
+	for part := range strings.SplitSeq(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if number, ok := strings.CutPrefix(part, "#"); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 1 || n > len(chapters) {
+				return nil, fmt.Errorf("no chapter %s", part)
+			}
+
+			chapter := chapters[n-1]
+			sections = append(sections, types.Section{Start: chapter.StartTime, End: chapter.EndTime})
+			continue
+		}
+
+		startText, endText, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a range like 12:30-15:10", part)
+		}
+
+		start, err := parseTimestamp(startText)
+		if err != nil {
+			return nil, err
+		}
+
+		end := 0.0
+		if strings.TrimSpace(endText) != "" {
+			end, err = parseTimestamp(endText)
+			if err != nil {
+				return nil, err
+			}
+
+			if end <= start {
+				return nil, fmt.Errorf("%q ends before it starts", part)
+			}
+
+			if duration > 0 && end > duration {
+				return nil, fmt.Errorf("%q goes past the end of the video (%s)", part, FormatDuration(duration))
+			}
+		}
+
+		if duration > 0 && start >= duration {
+			return nil, fmt.Errorf("%q starts after the end of the video (%s)", part, FormatDuration(duration))
+		}
+
+		sections = append(sections, types.Section{Start: start, End: end})
+	}
+
+	return sections, nil
+}
+
+// parseTimestamp reads SS, MM:SS or HH:MM:SS, with optional fractional
+// seconds.
+func parseTimestamp(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	parts := strings.Split(value, ":")
+	if value == "" || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+
+	seconds := 0.0
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || (i > 0 && n >= 60) || (i < len(parts)-1 && strings.Contains(part, ".")) {
+			return 0, fmt.Errorf("invalid time %q", value)
+		}
+
+		seconds = seconds*60 + n
+	}
+
+	return seconds, nil
+}
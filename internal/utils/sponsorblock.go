@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/config"
+)
+
+// sponsorBlockTemplate prints the segments SponsorBlock found for a video so
+// the removed time can be summed up once the download is done.
+const sponsorBlockTemplate = "after_move:%(sponsorblock_chapters)j"
+
+var sponsorBlockCategories = []string{
+	"sponsor", "intro", "outro", "selfpromo", "preview", "filler",
+	"interaction", "music_offtopic", "poi_highlight", "chapter", "all",
+}
+
+// SponsorBlockCategories returns the configured categories that yt-dlp
+// knows about, dropping and logging any it does not.
+func SponsorBlockCategories(cfg *config.Config) []string {
+	var categories []string
+	for _, category := range cfg.SponsorBlockCategories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		if !slices.Contains(sponsorBlockCategories, category) {
+			log.Printf("Warning: Unknown SponsorBlock category %q", category)
+			continue
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories
+}
+
+type sponsorSegment struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Category  string  `json:"category"`
+}
+
+// readSponsorBlockSummary reads the segments printed through
+// sponsorBlockTemplate and returns how many of them belong to the removed
+// categories and how many seconds they cover. Overlapping segments are only
+// counted once.
+func readSponsorBlockSummary(path string, removed []string) (int, float64) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Failed to read SponsorBlock segments: %v", err)
+		return 0, 0
+	}
+
+	all := slices.Contains(removed, "all")
+	count := 0
+	total := 0.0
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "NA" {
+			continue
+		}
+
+		var segments []sponsorSegment
+		if err := json.Unmarshal([]byte(line), &segments); err != nil {
+			log.Printf("Failed to parse SponsorBlock segments: %v", err)
+			continue
+		}
+
+		segments = slices.DeleteFunc(segments, func(s sponsorSegment) bool {
+			return s.EndTime <= s.StartTime || (!all && !slices.Contains(removed, s.Category))
+		})
+		sort.Slice(segments, func(i, j int) bool {
+			return segments[i].StartTime < segments[j].StartTime
+		})
+
+		count += len(segments)
+		end := 0.0
+		for _, segment := range segments {
+			start := max(segment.StartTime, end)
+			if segment.EndTime > start {
+				total += segment.EndTime - start
+				end = segment.EndTime
+			}
+		}
+	}
+
+	return count, total
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/types"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// subtitleTracks lists the manual tracks followed by the auto-generated ones
+// from the "subtitles" and "automatic_captions" maps of `yt-dlp -J`.
+func subtitleTracks(data map[string]any) []list.Item {
+	var items []list.Item
+	for _, source := range []struct {
+		field string
+		auto  bool
+	}{{"subtitles", false}, {"automatic_captions", true}} {
+		tracksAny, _ := data[source.field].(map[string]any)
+
+		var tracks []types.SubtitleTrack
+		for lang, entriesAny := range tracksAny {
+			// Live streams list their chat replay as a subtitle track.
+			if lang == "live_chat" {
+				continue
+			}
+
+			track := types.SubtitleTrack{Lang: lang, Auto: source.auto}
+			entries, _ := entriesAny.([]any)
+			for _, eAny := range entries {
+				e, ok := eAny.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				if name, _ := e["name"].(string); name != "" && track.Name == "" {
+					track.Name = name
+				}
+				if ext, _ := e["ext"].(string); ext != "" && !slices.Contains(track.Formats, ext) {
+					track.Formats = append(track.Formats, ext)
+				}
+			}
+
+			tracks = append(tracks, track)
+		}
+
+		sort.Slice(tracks, func(i, j int) bool {
+			return tracks[i].Lang < tracks[j].Lang
+		})
+		for _, track := range tracks {
+			items = append(items, track)
+		}
+	}
+
+	return items
+}
+
+// subtitleArgs returns the yt-dlp options for a subtitle selection.
+func subtitleArgs(sub *types.SubtitleRequest) []string {
+	langs := slices.Compact(slices.Sorted(slices.Values(append(slices.Clone(sub.Languages), sub.AutoLanguages...))))
+	if len(langs) == 0 {
+		return nil
+	}
+
+	var args []string
+	if len(sub.Languages) > 0 {
+		args = append(args, "--write-subs")
+	}
+	if len(sub.AutoLanguages) > 0 {
+		args = append(args, "--write-auto-subs")
+	}
+	args = append(args, "--sub-langs", strings.Join(langs, ","))
+
+	// YouTube serves vtt directly, the other formats are converted by ffmpeg.
+	switch sub.Format {
+	case "", "vtt":
+		args = append(args, "--sub-format", "vtt/best")
+	default:
+		args = append(args, "--sub-format", "best", "--convert-subs", sub.Format)
+	}
+
+	switch sub.Mode {
+	case types.SubtitleEmbed:
+		args = append(args, "--embed-subs")
+	case types.SubtitleOnly:
+		args = append(args, "--skip-download")
+	}
+
+	return args
+}
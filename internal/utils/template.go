@@ -0,0 +1,284 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xdagiz/xytz/internal/config"
+	"github.com/xdagiz/xytz/internal/types"
+)
+
+// templateFieldRegex matches a yt-dlp output template field such as
+// %(title)s, %(playlist_index)03d or %(upload_date>%Y-%m-%d|unknown)s.
+var templateFieldRegex = regexp.MustCompile(`%\(([^)]*)\)([-#0+ ]*\d*(?:\.\d+)?)([diouxXeEfFgGcrsaqBjlhSD])`)
+
+var strftimeReplacer = strings.NewReplacer(
+	"%Y", "2006",
+	"%y", "06",
+	"%m", "01",
+	"%d", "02",
+	"%H", "15",
+	"%M", "04",
+	"%S", "05",
+	"%b", "Jan",
+	"%B", "January",
+	"%a", "Mon",
+	"%A", "Monday",
+	"%%", "%",
+)
+
+func TemplateFields(video types.VideoItem, ext string) map[string]any {
+	fields := map[string]any{
+		"id":              video.ID,
+		"title":           video.VideoTitle,
+		"fulltitle":       video.VideoTitle,
+		"ext":             ext,
+		"uploader":        video.Uploader,
+		"channel":         video.Uploader,
+		"upload_date":     video.UploadDate,
+		"duration":        video.Duration,
+		"duration_string": FormatDuration(video.Duration),
+		"view_count":      video.Views,
+		"webpage_url":     "https://www.youtube.com/watch?v=" + video.ID,
+		"extractor":       "youtube",
+	}
+
+	if video.Uploader == "" {
+		fields["uploader"] = video.Channel
+		fields["channel"] = video.Channel
+	}
+
+	if video.PlaylistIndex > 0 {
+		fields["playlist_index"] = video.PlaylistIndex
+		fields["playlist_title"] = video.PlaylistTitle
+		fields["playlist"] = video.PlaylistTitle
+	}
+
+	if video.UploadDate != "" && len(video.UploadDate) == 8 {
+		fields["upload_year"] = video.UploadDate[:4]
+	}
+
+	return fields
+}
+
+// RenderTemplate expands a yt-dlp output template using the given fields.
+// It covers the commonly used subset of the syntax: alternatives (a,b),
+// defaults (|text), date formatting (>%Y-%m-%d) and printf conversions.
+// Missing fields render as "NA", like yt-dlp does.
+func RenderTemplate(tmpl string, fields map[string]any) string {
+	const percentMarker = "\x00"
+	tmpl = strings.ReplaceAll(tmpl, "%%", percentMarker)
+
+	out := templateFieldRegex.ReplaceAllStringFunc(tmpl, func(match string) string {
+		parts := templateFieldRegex.FindStringSubmatch(match)
+		expr, flags, conv := parts[1], parts[2], parts[3]
+
+		defaultValue := "NA"
+		if idx := strings.Index(expr, "|"); idx != -1 {
+			defaultValue = expr[idx+1:]
+			expr = expr[:idx]
+		}
+
+		dateFormat := ""
+		if idx := strings.Index(expr, ">"); idx != -1 {
+			dateFormat = expr[idx+1:]
+			expr = expr[:idx]
+		}
+
+		var value any
+		for _, name := range strings.Split(expr, ",") {
+			if v, ok := fields[strings.TrimSpace(name)]; ok && !isEmptyField(v) {
+				value = v
+				break
+			}
+		}
+
+		if value == nil {
+			return sanitizeField(defaultValue)
+		}
+
+		if dateFormat != "" {
+			if date, err := time.Parse("20060102", fmt.Sprint(value)); err == nil {
+				return sanitizeField(date.Format(strftimeReplacer.Replace(dateFormat)))
+			}
+		}
+
+		return sanitizeField(formatField(value, flags, conv))
+	})
+
+	return strings.ReplaceAll(out, percentMarker, "%")
+}
+
+func formatField(value any, flags, conv string) string {
+	switch conv {
+	case "d", "i":
+		n, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return fmt.Sprintf("%"+flags+"d", int64(n))
+	case "f", "F", "e", "E", "g", "G":
+		n, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return fmt.Sprintf("%"+flags+conv, n)
+	default:
+		if f, ok := value.(float64); ok && f == float64(int64(f)) {
+			value = int64(f)
+		}
+		return fmt.Sprintf("%"+flags+"s", fmt.Sprint(value))
+	}
+}
+
+func isEmptyField(v any) bool {
+	switch val := v.(type) {
+	case string:
+		return val == ""
+	case float64:
+		return val == 0
+	case int:
+		return val == 0
+	}
+
+	return v == nil
+}
+
+// Patterns used by yt-dlp's sanitize_filename. Newlines are first replaced
+// with a "\x00 " marker so that runs of them and leading or trailing ones can
+// be dropped.
+var (
+	timestampRegex       = regexp.MustCompile(`[0-9]+(?::[0-9]+)+`)
+	repeatedMarkerRegex  = regexp.MustCompile(`(?:\x00 ){2,}`)
+	surroundingMarkRegex = regexp.MustCompile(`^\x00 (?:\x00 |[ _-])*|(?:\x00 |[ _-])*\x00 $`)
+)
+
+// sanitizeField cleans a template value the way yt-dlp does by default, so
+// that rendered paths match the names of the files it writes. Characters not
+// allowed in file names become their full-width look-alikes, e.g. ":" → "：".
+func sanitizeField(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	value = timestampRegex.ReplaceAllStringFunc(value, func(ts string) string {
+		return strings.ReplaceAll(ts, ":", "_")
+	})
+
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '\n':
+			b.WriteString("\x00 ")
+		case r == '/':
+			b.WriteRune('⧸')
+		case r == '\\':
+			b.WriteRune('⧹')
+		case strings.ContainsRune(`"*:<>?|`, r):
+			b.WriteRune(r + 0xfee0)
+		case r < 32 || r == 127:
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	result := repeatedMarkerRegex.ReplaceAllString(b.String(), "\x00 ")
+	result = surroundingMarkRegex.ReplaceAllString(result, "")
+	result = strings.ReplaceAll(result, "\x00", "")
+	if result == "" {
+		return "_"
+	}
+
+	return result
+}
+
+// SectionTemplate adds the section bounds to an output template so that clips
+// of a video do not overwrite each other or the full download.
+func SectionTemplate(tmpl string) string {
+	if tmpl == "" {
+		tmpl = config.DefaultOutputTemplate
+	}
+
+	if strings.Contains(tmpl, "%(section_") {
+		return tmpl
+	}
+
+	base, ok := strings.CutSuffix(tmpl, ".%(ext)s")
+	if !ok {
+		return tmpl
+	}
+
+	return base + " [%(section_start)d-%(section_end)d].%(ext)s"
+}
+
+// SuffixTemplate adds suffix to the file name of an output template, to keep
+// a new download next to an existing file of the same name.
+func SuffixTemplate(tmpl, suffix string) string {
+	if tmpl == "" {
+		tmpl = config.DefaultOutputTemplate
+	}
+
+	if base, ok := strings.CutSuffix(tmpl, ".%(ext)s"); ok {
+		return base + suffix + ".%(ext)s"
+	}
+
+	return tmpl + suffix
+}
+
+// OutputPath joins the download directory with the output template, unless
+// the template is already an absolute path.
+func OutputPath(downloadPath, tmpl string) string {
+	if tmpl == "" {
+		tmpl = config.DefaultOutputTemplate
+	}
+
+	if filepath.IsAbs(tmpl) {
+		return tmpl
+	}
+
+	return filepath.Join(downloadPath, tmpl)
+}
+
+// DestinationDir returns the folder a download is written to, including the
+// folders of the output template. Template folders are filled in from video
+// when all their fields are known, and left out otherwise. Playlists pass an
+// empty video, as their fields are only known once yt-dlp runs.
+func DestinationDir(req types.DownloadRequest, video types.VideoItem) string {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.GetDefault()
+	}
+
+	req = ResolveRequest(req, cfg)
+	dir := filepath.Dir(OutputPath(req.OutputDir, req.OutputTemplate))
+
+	fields := TemplateFields(video, "")
+	for _, match := range templateFieldRegex.FindAllStringSubmatch(dir, -1) {
+		if video.ID == "" || !templateFieldKnown(match[1], fields) {
+			return filepath.Dir(dir[:strings.Index(dir, match[0])])
+		}
+	}
+
+	return RenderTemplate(dir, fields)
+}
+
+// templateFieldKnown reports whether a template field expression renders to
+// a real value rather than "NA".
+func templateFieldKnown(expr string, fields map[string]any) bool {
+	if strings.Contains(expr, "|") {
+		return true
+	}
+
+	expr, _, _ = strings.Cut(expr, ">")
+	for _, name := range strings.Split(expr, ",") {
+		if v, ok := fields[strings.TrimSpace(name)]; ok && !isEmptyField(v) {
+			return true
+		}
+	}
+
+	return false
+}
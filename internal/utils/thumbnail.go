@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/xdagiz/xytz/internal/types"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// thumbnailItems lists the thumbnails from `yt-dlp -J`, largest first.
+// Entries without a known size are only shown when no size is known at all.
+func thumbnailItems(data map[string]any) []list.Item {
+	thumbnailsAny, _ := data["thumbnails"].([]any)
+
+	var thumbnails []types.ThumbnailItem
+	for _, tAny := range thumbnailsAny {
+		t, ok := tAny.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		thumbURL, _ := t["url"].(string)
+		if thumbURL == "" {
+			continue
+		}
+
+		id := fmt.Sprint(t["id"])
+		thumbnails = append(thumbnails, types.ThumbnailItem{
+			ID:     id,
+			URL:    thumbURL,
+			Width:  int(parseFloat(t["width"])),
+			Height: int(parseFloat(t["height"])),
+			Ext:    thumbnailExt(thumbURL),
+		})
+	}
+
+	sized := slices.DeleteFunc(slices.Clone(thumbnails), func(t types.ThumbnailItem) bool {
+		return t.Width == 0 || t.Height == 0
+	})
+	if len(sized) > 0 {
+		thumbnails = sized
+	}
+
+	slices.SortStableFunc(thumbnails, func(a, b types.ThumbnailItem) int {
+		return cmp.Compare(b.Width*b.Height, a.Width*a.Height)
+	})
+
+	items := make([]list.Item, len(thumbnails))
+	for i, t := range thumbnails {
+		items[i] = t
+	}
+
+	return items
+}
+
+func thumbnailExt(thumbURL string) string {
+	ext := "jpg"
+	if u, err := url.Parse(thumbURL); err == nil {
+		if e := strings.TrimPrefix(path.Ext(u.Path), "."); e != "" {
+			ext = strings.ToLower(e)
+		}
+	}
+
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+
+	return ext
+}
+
+// downloadThumbnail saves the requested thumbnail next to where the video
+// would go. yt-dlp's --write-thumbnail always picks its preferred image, so
+// the chosen one is fetched directly and converted with ffmpeg when needed.
+func downloadThumbnail(program *tea.Program, job *downloadJob) {
+	req := job.req
+	thumb := req.Thumbnail
+
+	video := types.VideoItem{ID: req.VideoID, VideoTitle: req.Title, Channel: req.Channel, Duration: req.Duration}
+	if video.ID == "" {
+		video.ID = ExtractVideoID(req.URL)
+	}
+	target := RenderTemplate(OutputPath(req.OutputDir, req.OutputTemplate), TemplateFields(video, thumb.Format))
+
+	sourceExt := thumbnailExt(thumb.URL)
+	needsConversion := sourceExt != thumb.Format
+	if needsConversion && !HasFFmpeg(job.cfg.FFmpegPath) {
+		failDownload(program, job, "Converting thumbnails needs ffmpeg")
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		failDownload(program, job, fmt.Sprintf("Download error: %v", err))
+		return
+	}
+
+	program.Send(types.DownloadStartedMsg{JobID: req.JobID})
+
+	downloaded := strings.TrimSuffix(target, filepath.Ext(target)) + ".thumbnail." + sourceExt
+	if !needsConversion {
+		downloaded = target
+	}
+
+	if err := fetchThumbnail(job.ctx, httpClient(job.cfg.Network), program, req.JobID, thumb.URL, downloaded); err != nil {
+		os.Remove(downloaded)
+		if job.ctx.Err() == context.Canceled {
+			cancelledDownload(program, job)
+			return
+		}
+
+		log.Printf("Thumbnail download error: %v", err)
+		failDownload(program, job, fmt.Sprintf("Download error: %v", err))
+		return
+	}
+
+	if needsConversion {
+		program.Send(types.ProgressMsg{JobID: req.JobID, Percent: 100, Destination: target, Phase: types.PhaseConvertingThumbnail})
+
+		ffmpegPath := cmp.Or(job.cfg.FFmpegPath, "ffmpeg")
+		out, err := exec.CommandContext(job.ctx, ffmpegPath, "-y", "-loglevel", "error", "-i", downloaded, target).CombinedOutput()
+		os.Remove(downloaded)
+		if err != nil {
+			log.Printf("Thumbnail conversion error: %v: %s", err, out)
+			failDownload(program, job, fmt.Sprintf("Thumbnail conversion error: %v", err))
+			return
+		}
+	}
+
+	if err := RemoveUnfinished(req.URL); err != nil {
+		log.Printf("Failed to remove from unfinished list: %v", err)
+	}
+
+	if err := AddCompleted(NewCompletedDownload(req, target)); err != nil {
+		log.Printf("Failed to add to completed list: %v", err)
+	}
+
+	filePaths := []string{target}
+	program.Send(types.DownloadFinishedMsg{JobID: req.JobID, URL: req.URL, FilePaths: filePaths})
+	RunHook(program, job.cfg, HookComplete, req, filePaths, "")
+}
+
+func fetchThumbnail(ctx context.Context, client *http.Client, program *tea.Program, jobID int, thumbURL, dest string) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, thumbURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %s", resp.Status)
+	}
+
+	file, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	total := float64(resp.ContentLength)
+	progress := types.ProgressMsg{JobID: jobID, Destination: dest, TotalBytes: max(total, 0), Phase: types.PhaseDownloading}
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := file.Write(buf[:n]); err != nil {
+				return err
+			}
+
+			progress.DownloadedBytes += float64(n)
+			if total > 0 {
+				progress.Percent = progress.DownloadedBytes / total * 100
+			}
+			program.Send(progress)
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
@@ -6,16 +6,125 @@ import (
 	"os"
 
 	"path/filepath"
+	"sync"
 	"time"
+
+	"github.com/xdagiz/xytz/internal/types"
 )
 
 const UnfinishedFileName = ".xytz_unfinished.json"
 
+var unfinishedMutex sync.Mutex
+
 type UnfinishedDownload struct {
-	URL       string    `json:"url"`
-	FormatID  string    `json:"format_id"`
-	Title     string    `json:"title"`
-	Timestamp time.Time `json:"timestamp"`
+	URL            string                  `json:"url"`
+	FormatID       string                  `json:"format_id"`
+	Title          string                  `json:"title"`
+	VideoID        string                  `json:"video_id,omitempty"`
+	Channel        string                  `json:"channel,omitempty"`
+	Duration       float64                 `json:"duration,omitempty"`
+	Options        map[string]bool         `json:"options,omitempty"`
+	OutputDir      string                  `json:"output_dir,omitempty"`
+	OutputTemplate string                  `json:"output_template,omitempty"`
+	Playlist       bool                    `json:"playlist,omitempty"`
+	SkipArchived   bool                    `json:"skip_archived,omitempty"`
+	AudioFormat    string                  `json:"audio_format,omitempty"`
+	AudioQuality   string                  `json:"audio_quality,omitempty"`
+	Sections       []types.Section         `json:"sections,omitempty"`
+	ForceKeyframes bool                    `json:"force_keyframes,omitempty"`
+	Subtitles      *types.SubtitleRequest  `json:"subtitles,omitempty"`
+	Thumbnail      *types.ThumbnailRequest `json:"thumbnail,omitempty"`
+	ExtraArgs      []string                `json:"extra_args,omitempty"`
+	PartialPath    string                  `json:"partial_path,omitempty"`
+	TotalBytes     float64                 `json:"total_bytes,omitempty"`
+	Timestamp      time.Time               `json:"timestamp"`
+}
+
+func NewUnfinishedDownload(req types.DownloadRequest) UnfinishedDownload {
+	options := make(map[string]bool, len(req.Options))
+	for _, opt := range req.Options {
+		options[opt.ConfigField] = opt.Enabled
+	}
+
+	return UnfinishedDownload{
+		URL:            req.URL,
+		FormatID:       req.FormatID,
+		Title:          req.Title,
+		VideoID:        req.VideoID,
+		Channel:        req.Channel,
+		Duration:       req.Duration,
+		Options:        options,
+		OutputDir:      req.OutputDir,
+		OutputTemplate: req.OutputTemplate,
+		Playlist:       req.Playlist,
+		SkipArchived:   req.SkipArchived,
+		AudioFormat:    req.AudioFormat,
+		AudioQuality:   req.AudioQuality,
+		Sections:       req.Sections,
+		ForceKeyframes: req.ForceKeyframes,
+		Subtitles:      req.Subtitles,
+		Thumbnail:      req.Thumbnail,
+		ExtraArgs:      req.ExtraArgs,
+		Timestamp:      time.Now(),
+	}
+}
+
+// Request rebuilds the download request the record was created from. Records
+// written by older versions have no options, in which case Options is nil.
+func (d UnfinishedDownload) Request() types.DownloadRequest {
+	var options []types.DownloadOption
+	if d.Options != nil {
+		options = types.DownloadOptions()
+		for i := range options {
+			options[i].Enabled = d.Options[options[i].ConfigField]
+		}
+	}
+
+	return types.DownloadRequest{
+		URL:            d.URL,
+		FormatID:       d.FormatID,
+		Title:          d.Title,
+		VideoID:        d.VideoID,
+		Channel:        d.Channel,
+		Duration:       d.Duration,
+		Options:        options,
+		OutputDir:      d.OutputDir,
+		OutputTemplate: d.OutputTemplate,
+		Playlist:       d.Playlist,
+		SkipArchived:   d.SkipArchived,
+		AudioFormat:    d.AudioFormat,
+		AudioQuality:   d.AudioQuality,
+		Sections:       d.Sections,
+		ForceKeyframes: d.ForceKeyframes,
+		Subtitles:      d.Subtitles,
+		Thumbnail:      d.Thumbnail,
+		ExtraArgs:      d.ExtraArgs,
+	}
+}
+
+// PartialSize returns the number of bytes already on disk for the download.
+func (d UnfinishedDownload) PartialSize() int64 {
+	if d.PartialPath == "" {
+		return 0
+	}
+
+	for _, path := range []string{d.PartialPath + ".part", d.PartialPath} {
+		if info, err := os.Stat(path); err == nil {
+			return info.Size()
+		}
+	}
+
+	return 0
+}
+
+// EstimatedPercent compares the partial file with the expected size. It
+// returns -1 when the expected size is unknown.
+func (d UnfinishedDownload) EstimatedPercent() float64 {
+	if d.TotalBytes <= 0 {
+		return -1
+	}
+
+	return min(float64(d.PartialSize())/d.TotalBytes*100, 100)
 }
 
 func GetUnfinishedFilePath() string {
@@ -65,6 +174,9 @@ func SaveUnfinished(downloads []UnfinishedDownload) error {
 }
 
 func AddUnfinished(download UnfinishedDownload) error {
+	unfinishedMutex.Lock()
+	defer unfinishedMutex.Unlock()
+
 	downloads, err := LoadUnfinished()
 	if err != nil {
 		return err
@@ -72,6 +184,10 @@ func AddUnfinished(download UnfinishedDownload) error {
 
 	for i, d := range downloads {
 		if d.URL == download.URL {
+			if download.PartialPath == "" {
+				download.PartialPath = d.PartialPath
+				download.TotalBytes = d.TotalBytes
+			}
 			downloads[i] = download
 			return SaveUnfinished(downloads)
 		}
@@ -81,7 +197,32 @@ func AddUnfinished(download UnfinishedDownload) error {
 	return SaveUnfinished(downloads)
 }
 
+// UpdateUnfinishedProgress records the file being written for url and its
+// expected size, so that /resume can show how much is already on disk.
+func UpdateUnfinishedProgress(url, partialPath string, totalBytes float64) error {
+	unfinishedMutex.Lock()
+	defer unfinishedMutex.Unlock()
+
+	downloads, err := LoadUnfinished()
+	if err != nil {
+		return err
+	}
+
+	for i, d := range downloads {
+		if d.URL == url {
+			downloads[i].PartialPath = partialPath
+			downloads[i].TotalBytes = totalBytes
+			return SaveUnfinished(downloads)
+		}
+	}
+
+	return nil
+}
+
 func RemoveUnfinished(url string) error {
+	unfinishedMutex.Lock()
+	defer unfinishedMutex.Unlock()
+
 	downloads, err := LoadUnfinished()
 	if err != nil {
 		return err
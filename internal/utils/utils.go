@@ -5,7 +5,7 @@ import (
 	"os/exec"
 )
 
-func bytesToHuman(bytes float64) string {
+func FormatBytes(bytes float64) string {
 	if bytes == 0 {
 		return "Unknown Size"
 	}
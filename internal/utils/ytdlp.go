@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xdagiz/xytz/internal/config"
+)
+
+// Release dates of the yt-dlp versions that introduced the options xytz
+// relies on. yt-dlp versions are dates (YYYY.MM.DD), so they compare as strings.
+const (
+	progressTemplateVersion = "2021.10.09"
+	printToFileVersion      = "2021.11.10"
+	downloadSectionsVersion = "2022.06.22"
+)
+
+var (
+	ytdlpVersions   = make(map[string]string)
+	ytdlpVersionsMu sync.Mutex
+)
+
+// BaseArgs returns the options every yt-dlp run shares: authentication and
+// network settings.
+func BaseArgs(cfg *config.Config) []string {
+	return append(CookieArgs(cfg), NetworkArgs(cfg.Network)...)
+}
+
+// waitRetry waits delay before the next attempt and reports whether ctx was
+// cancelled in the meantime.
+func waitRetry(ctx context.Context, delay time.Duration) bool {
+	select {
+	case <-time.After(delay):
+		return false
+	case <-ctx.Done():
+		return true
+	}
+}
+
+func ytdlpBinary(path string) string {
+	if path == "" {
+		return "yt-dlp"
+	}
+
+	return path
+}
+
+// YTDLPVersion returns the version reported by `yt-dlp --version`. The
+// result is cached per binary path for the lifetime of the process.
+func YTDLPVersion(path string) string {
+	path = ytdlpBinary(path)
+
+	ytdlpVersionsMu.Lock()
+	defer ytdlpVersionsMu.Unlock()
+
+	if version, ok := ytdlpVersions[path]; ok {
+		return version
+	}
+
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		log.Printf("Failed to get yt-dlp version: %v", err)
+		return ""
+	}
+
+	version := strings.TrimSpace(string(out))
+	ytdlpVersions[path] = version
+	return version
+}
+
+func ytdlpAtLeast(path, minVersion string) bool {
+	version := YTDLPVersion(path)
+	if version == "" {
+		return false
+	}
+
+	return version >= minVersion
+}
+
+func SupportsProgressTemplate(path string) bool {
+	return ytdlpAtLeast(path, progressTemplateVersion)
+}
+
+func SupportsPrintToFile(path string) bool {
+	return ytdlpAtLeast(path, printToFileVersion)
+}
+
+func SupportsDownloadSections(path string) bool {
+	return ytdlpAtLeast(path, downloadSectionsVersion)
+}
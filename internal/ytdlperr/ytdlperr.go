@@ -0,0 +1,167 @@
+// Package ytdlperr turns yt-dlp error output into typed errors with a
+// user-facing message and a retry policy.
+package ytdlperr
+
+import (
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	Unknown           Kind = "unknown"
+	Network           Kind = "network"
+	RateLimited       Kind = "rate_limited"
+	Forbidden         Kind = "forbidden"
+	NotFound          Kind = "not_found"
+	AgeRestricted     Kind = "age_restricted"
+	SignInRequired    Kind = "sign_in_required"
+	GeoBlocked        Kind = "geo_blocked"
+	Unavailable       Kind = "unavailable"
+	FormatUnavailable Kind = "format_unavailable"
+	FFmpegMissing     Kind = "ffmpeg_missing"
+)
+
+// RetryPolicy describes how often an operation that failed with a given kind
+// of error is attempted. MaxAttempts includes the first attempt, so a policy
+// with MaxAttempts of 1 never retries.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var noRetry = RetryPolicy{MaxAttempts: 1}
+
+// Next returns the backoff before the attempt that follows attempt (counted
+// from 1), and false once the policy is exhausted.
+func (p RetryPolicy) Next(attempt int) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	delay := p.BaseDelay << (attempt - 1)
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay <= 0) {
+		delay = p.MaxDelay
+	}
+
+	return delay, true
+}
+
+type Error struct {
+	Kind    Kind
+	Message string
+	Line    string
+	Policy  RetryPolicy
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Retryable() bool {
+	return e.Policy.MaxAttempts > 1
+}
+
+type rule struct {
+	kind     Kind
+	message  string
+	policy   RetryPolicy
+	patterns []string
+}
+
+// rules are checked in order, so the more specific ones come first: an
+// age-restricted video, for example, is also reported with an HTTP error.
+var rules = []rule{
+	{
+		kind:     FFmpegMissing,
+		message:  "ffmpeg is not installed; it is needed to merge or convert this download",
+		policy:   noRetry,
+		patterns: []string{"ffmpeg is not installed", "ffmpeg not found", "ffprobe and ffmpeg not found", "ffmpeg-location"},
+	},
+	{
+		kind:     AgeRestricted,
+		message:  "This video is age-restricted; sign in with cookies to download it",
+		policy:   noRetry,
+		patterns: []string{"confirm your age", "age-restricted", "age restricted", "inappropriate for some users"},
+	},
+	{
+		kind:     SignInRequired,
+		message:  "YouTube requires signing in for this video; use cookies to authenticate",
+		policy:   noRetry,
+		patterns: []string{"sign in to confirm", "members-only", "available to this channel's members", "join this channel", "--cookies-from-browser or --cookies", "login required", "requires authentication"},
+	},
+	{
+		kind:     GeoBlocked,
+		message:  "This video is not available in your country",
+		policy:   noRetry,
+		patterns: []string{"not available in your country", "geo restriction", "geo-restricted", "blocked it in your country", "not made this video available in your country"},
+	},
+	{
+		kind:     Unavailable,
+		message:  "This playlist is private; set up cookies (see /auth) to access it",
+		policy:   noRetry,
+		patterns: []string{"private playlist", "this playlist is private"},
+	},
+	{
+		kind:     Unavailable,
+		message:  "This video is private or has been removed",
+		policy:   noRetry,
+		patterns: []string{"private video", "video has been removed", "video unavailable", "no longer available", "account associated with this video has been terminated", "removed for violating"},
+	},
+	{
+		kind:     FormatUnavailable,
+		message:  "The requested format is not available for this video",
+		policy:   noRetry,
+		patterns: []string{"requested format is not available", "requested format not available", "no video formats found"},
+	},
+	{
+		kind:     RateLimited,
+		message:  "YouTube is rate limiting requests (HTTP 429)",
+		policy:   RetryPolicy{MaxAttempts: 4, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute},
+		patterns: []string{"http error 429", "too many requests"},
+	},
+	{
+		kind:     Forbidden,
+		message:  "YouTube refused the request (HTTP 403)",
+		policy:   RetryPolicy{MaxAttempts: 3, BaseDelay: 5 * time.Second, MaxDelay: time.Minute},
+		patterns: []string{"http error 403", "403: forbidden"},
+	},
+	{
+		kind:     NotFound,
+		message:  "Not found",
+		policy:   noRetry,
+		patterns: []string{"http error 404", "requested entity was not found", "does not exist"},
+	},
+	{
+		kind:     Network,
+		message:  "Please Check Your Internet connection",
+		policy:   RetryPolicy{MaxAttempts: 5, BaseDelay: 2 * time.Second, MaxDelay: 30 * time.Second},
+		patterns: []string{"[errno 101]", "[errno -3]", "[errno -2]", "[errno 110]", "[errno 111]", "network is unreachable", "temporary failure in name resolution", "name or service not known", "getaddrinfo failed", "connection reset", "connection refused", "timed out", "urlopen error", "remote end closed connection"},
+	},
+}
+
+// Classify looks through yt-dlp's stderr lines and returns the first error
+// it recognises. Unrecognised "ERROR:" lines are returned as Unknown, and nil
+// is returned when there is no error at all.
+func Classify(lines []string) *Error {
+	for _, r := range rules {
+		for _, line := range lines {
+			lower := strings.ToLower(line)
+			for _, pattern := range r.patterns {
+				if strings.Contains(lower, pattern) {
+					return &Error{Kind: r.kind, Message: r.message, Line: line, Policy: r.policy}
+				}
+			}
+		}
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		if msg, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "ERROR:"); ok {
+			return &Error{Kind: Unknown, Message: strings.TrimSpace(msg), Line: lines[i], Policy: noRetry}
+		}
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/xdagiz/xytz/internal/app"
 	"github.com/xdagiz/xytz/internal/config"
+	"github.com/xdagiz/xytz/internal/utils"
 
 	tea "github.com/charmbracelet/bubbletea"
 	zone "github.com/lrstanley/bubblezone"
@@ -17,7 +18,7 @@ func main() {
 	defer zone.Close()
 
 	m := app.NewModel()
-	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithOutput(utils.TerminalOutput))
 	m.Program = p
 
 	homeDir, err := os.UserHomeDir()
@@ -46,6 +47,7 @@ func main() {
 		os.Exit(1)
 	}
 
+	m.ClearTerminalProgress()
 	saveConfigOptions(m)
 }
 
@@ -56,14 +58,11 @@ func saveConfigOptions(m *app.Model) {
 		return
 	}
 
-	for _, opt := range m.Search.DownloadOptions {
-		switch opt.ConfigField {
-		case "EmbedSubtitles":
-			cfg.EmbedSubtitles = opt.Enabled
-		case "EmbedMetadata":
-			cfg.EmbedMetadata = opt.Enabled
-		case "EmbedChapters":
-			cfg.EmbedChapters = opt.Enabled
+	// With a profile active the toggles reflect the profile, not the user's
+	// defaults.
+	if m.Search.Profile == "" {
+		for _, opt := range m.Search.DownloadOptions {
+			cfg.SetOption(opt.ConfigField, opt.Enabled)
 		}
 	}
 